@@ -0,0 +1,201 @@
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitPolygon(t *testing.T) {
+	square := []cp.Vector{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}
+	left, right := splitPolygon(square, cp.Vector{X: 4, Y: 5}, cp.Vector{X: 0, Y: 1})
+
+	if !assert.Len(t, left, 1) || !assert.Len(t, right, 1) {
+		return
+	}
+	assert.InDelta(t, 40, cp.AreaForPoly(len(left[0]), left[0], 0), 1e-9)
+	assert.InDelta(t, 60, cp.AreaForPoly(len(right[0]), right[0], 0), 1e-9)
+	assertConvex(t, left[0])
+	assertConvex(t, right[0])
+}
+
+// u is a concave, u-shaped polygon with an area of 80
+var u = []cp.Vector{
+	{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 7, Y: 10},
+	{X: 7, Y: 5}, {X: 3, Y: 5}, {X: 3, Y: 10}, {X: 0, Y: 10},
+}
+
+func TestSplitConcavePolygon(t *testing.T) {
+	// cut through both arms of the u
+	left, right := splitPolygon(u, cp.Vector{X: 5, Y: 8}, cp.Vector{X: 1, Y: 0})
+
+	if !assert.Len(t, left, 2) || !assert.Len(t, right, 1) {
+		return
+	}
+	for _, part := range left {
+		assert.InDelta(t, 6, cp.AreaForPoly(len(part), part, 0), 1e-9)
+	}
+	assert.InDelta(t, 68, cp.AreaForPoly(len(right[0]), right[0], 0), 1e-9)
+}
+
+func TestDecompose(t *testing.T) {
+	parts := decompose(u)
+
+	assert.Greater(t, len(parts), 1)
+	var area float64
+	for _, part := range parts {
+		assertConvex(t, part)
+		area += cp.AreaForPoly(len(part), part, 0)
+	}
+	assert.InDelta(t, 80, area, 1e-9)
+
+	// convex polygons stay in one piece
+	assert.Len(t, decompose(u[:4]), 1)
+}
+
+func TestConcaveAsteroid(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+
+	a := NewAsteroid(g.space, image.Rect(0, 0, 200, 200))
+	a.Generator = NoisyCircleGenerator{Vertices: 32, Noise: .4, Concave: true}
+	a.generate(1)
+
+	area := cp.AreaForPoly(len(a.Outline), a.Outline, 0)
+	var shapes int
+	a.Body.EachShape(func(*cp.Shape) {
+		shapes++
+	})
+	assert.Greater(t, shapes, 1)
+	assert.InDelta(t, area*a.density, a.Mass(), a.Mass()*1e-6)
+
+	// fracture splits the concave outline
+	mass := a.Mass()
+	g.asteroids = append(g.asteroids, a)
+	g.fracture(a, a.LocalToWorld(a.Body.CenterOfGravity()), cp.Vector{X: 0, Y: 1})
+
+	var total float64
+	for _, f := range g.asteroids {
+		total += f.Mass()
+	}
+	for _, d := range g.debris {
+		total += d.Mass()
+	}
+	assert.Greater(t, len(g.asteroids), 1)
+	assert.InDelta(t, mass, total, mass*1e-6)
+}
+
+func TestAsteroidFracture(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+
+	var events []Event
+	g.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+
+	a := NewAsteroid(g.space, image.Rect(0, 0, 200, 200))
+	a.Generator = NoisyCircleGenerator{Vertices: 16}
+	a.generate(1)
+	a.SetVelocity(10, -5)
+	a.SetAngularVelocity(.5)
+	g.asteroids = append(g.asteroids, a)
+
+	b := NewBot(g.space, 1, testHull(t))
+	g.bots = append(g.bots, b)
+	g.attach(b, a, a.LocalToWorld(a.Body.CenterOfGravity()))
+
+	mass := a.Mass()
+	momentum := a.Velocity().Mult(mass)
+	center := a.LocalToWorld(a.Body.CenterOfGravity())
+
+	g.fracture(a, center, cp.Vector{X: 1, Y: 1}.Normalize())
+
+	if !assert.Len(t, g.asteroids, 2) {
+		return
+	}
+	assert.False(t, g.space.ContainsBody(a.Body))
+	assert.Nil(t, b.tether)
+
+	var total float64
+	var p cp.Vector
+	for _, f := range g.asteroids {
+		total += f.Mass()
+		p = p.Add(f.Velocity().Mult(f.Mass()))
+		assert.Equal(t, a.AngularVelocity(), f.AngularVelocity())
+	}
+	assert.InDelta(t, mass, total, mass*1e-6)
+	assert.InDelta(t, momentum.X, p.X, momentum.Length()*1e-6)
+	assert.InDelta(t, momentum.Y, p.Y, momentum.Length()*1e-6)
+
+	if !assert.Len(t, events, 1) {
+		return
+	}
+	assert.Equal(t, EventAsteroidFracture, events[0].Type)
+}
+
+func TestSmallFragmentsTurnToDust(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+
+	a := NewAsteroid(g.space, image.Rect(0, 0, 100, 100))
+	a.Generator = NoisyCircleGenerator{Vertices: 16}
+	a.generate(1)
+	g.asteroids = append(g.asteroids, a)
+
+	// chip off a small piece at the edge
+	g.fracture(a, a.LocalToWorld(cp.Vector{X: -45, Y: 0}), cp.Vector{X: 0, Y: 1})
+
+	assert.Len(t, g.asteroids, 1)
+	assert.Len(t, g.debris, 1)
+}
+
+func TestHardImpactFracturesAsteroids(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+
+	a := NewAsteroid(g.space, image.Rect(0, 0, 50, 50))
+	a.generate(1)
+	a.SetVelocity(300, 0)
+	b := NewAsteroid(g.space, image.Rect(0, 0, 50, 50))
+	b.SetPosition(cp.Vector{X: 100, Y: 0})
+	b.generate(2)
+	b.SetVelocity(-300, 0)
+	g.asteroids = append(g.asteroids, a, b)
+
+	for i := 0; i < 30; i++ {
+		g.space.Step(1. / 60)
+	}
+	assert.Greater(t, len(g.asteroids)+len(g.debris), 2)
+	assert.False(t, g.space.ContainsBody(a.Body))
+}
+
+func TestComposition(t *testing.T) {
+	outline := NoisyCircleGenerator{Vertices: 16}.Generate(1, image.Rect(0, 0, 200, 200))
+	for seed := int64(0); seed < 20; seed++ {
+		c := NewComposition(seed, outline)
+		assert.Equal(t, c, NewComposition(seed, outline), "seed %d", seed)
+		for _, d := range c.Deposits {
+			assert.True(t, pointInPolygon(d.Center, outline), "seed %d: %v", seed, d.Center)
+			assert.NotEqual(t, ResourceSilicate, d.Resource)
+
+			res, richness := c.Ore(d.Center)
+			assert.GreaterOrEqual(t, richness, d.Richness)
+			assert.GreaterOrEqual(t, c.Yield(d.Center), c.Matrix.Type().Yield)
+			if richness == d.Richness {
+				assert.Equal(t, d.Resource, res)
+			}
+		}
+	}
+
+	a := NewAsteroid(cp.NewSpace(), image.Rect(0, 0, 200, 200))
+	a.generate(3)
+	area := cp.AreaForPoly(len(a.Outline), a.Outline, 0)
+	assert.InDelta(t, area*a.Composition.Density(), a.Mass(), a.Mass()*1e-6)
+}
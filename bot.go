@@ -2,11 +2,27 @@ package main
 
 import (
 	"math"
+	"sort"
 
 	"github.com/jakecoffman/cp"
 )
 
-const botFrictionCoeff = 0.4
+const (
+	botFrictionCoeff = 0.4
+
+	// botMinMass is the mass a bot can not burn below.
+	// Chipmunk requires bodies to have a positive mass.
+	botMinMass = .1
+
+	// impulseCost is the energy the thrusters use per unit
+	// of impulse. The reactor burns energy / efficiency mass
+	// to provide it.
+	impulseCost = 1. / 1000
+
+	// contactRange is the maximum gap between two objects
+	// for them to be considered touching
+	contactRange = 2
+)
 
 type (
 	Bot struct {
@@ -16,6 +32,9 @@ type (
 
 		id int16
 
+		hull   *Hull
+		radius float64
+
 		// Components
 
 		// leonhardEfficiency (< 1) is the efficiency of the
@@ -30,61 +49,120 @@ type (
 		thrustStep func(int16) float64
 		// scan FOV in degrees
 		scanFOV func() float64
+		// scanRange is the maximum distance of scanned objects
+		scanRange func() float64
+		// mineStrength is the maximum mining strength
+		mineStrength func() float64
+		// turnImpulse is the maximum angular impulse the
+		// attitude thrusters can apply per cycle
+		turnImpulse func() float64
 
 		impulses []cp.Vector
-		thrust   cp.Vector
-		angle    float64
+		// impact is the accumulated energy of collisions
+		// since the last cycle
+		impact float64
+
+		// snapshot is what the machine sees of the bot
+		// and intent what it wants to do
+		snapshot BotSnapshot
+		intent   Intent
+
+		// remote is the object selected by RemoteID
+		remote *cp.Shape
+		tether *Tether
 
 		machine *Machine
+
+		// destroyed bots are removed at the end of the cycle
+		destroyed bool
 	}
-)
 
-func BotRunner(g *Game, bc <-chan *Bot) {
-	for {
-		b, ok := <-bc
-		if !ok {
-			break
-		}
-		b.machine.Run()
-		g.wg.Done()
+	// BotSnapshot is the state of a bot as seen by its
+	// machine.
+	//
+	// It is taken before the machines run and does not
+	// change while they run.
+	BotSnapshot struct {
+		ID       int16
+		Velocity cp.Vector
+		Energy   int16
+		Heading  int16
+		Impact   int16
+		// Visible are the shapes in the scanner's FOV,
+		// closest first. The scan runs on first use, see
+		// Bot.visible.
+		Visible []*cp.Shape
+
+		scanned bool
+		scan    *scanIndex
 	}
-}
 
-func NewBot(sp *cp.Space, id int16) *Bot {
+	// Intent is what the machine of a bot requested during
+	// a cycle. Intents are applied to the world after all
+	// machines ran.
+	Intent struct {
+		Thrust cp.Vector
+		// Impulse is thrust along the heading
+		Impulse float64
+		// Turn is the change in angular velocity in
+		// radians per second
+		Turn float64
+		// Transfer is the energy to give to (> 0) or
+		// take from (< 0) the remote object
+		Transfer float64
+		// Mining is the mining strength
+		Mining float64
+		// Dock and Undock connect to or release the
+		// remote bot
+		Dock, Undock bool
+		// Tether and Release attach a tether to the remote
+		// asteroid or release it
+		Tether, Release bool
+	}
+)
+
+// NewBot builds a bot following the blueprint of hull
+func NewBot(sp *cp.Space, id int16, hull *Hull) *Bot {
 	b := &Bot{
-		Body: sp.AddBody(cp.NewBody(100, 10)),
+		Body: sp.AddBody(cp.NewBody(hull.Mass, cp.MomentForCircle(hull.Mass, 0, hull.Radius, cp.Vector{}))),
 
 		space: sp,
 
 		id: id,
 
+		hull:   hull,
+		radius: hull.Radius,
+
 		leonhardEfficiency: func() float64 {
-			return .65
+			return hull.Reactor.Efficiency
 		},
-		thrustStep: func(step int16) float64 {
-			switch true {
-			case step < 100:
-				return 80
-			case step < 200:
-				return 140
-			default:
-				return 200
-			}
+		thrustStep: hull.Thrusters.Force,
+		scanFOV: func() float64 {
+			return hull.Scanner.FOV
+		},
+		scanRange: func() float64 {
+			return hull.Scanner.Range
+		},
+		mineStrength: func() float64 {
+			return hull.Miner.Strength
+		},
+		turnImpulse: func() float64 {
+			return hull.Thrusters.TurnImpulse
 		},
 
 		impulses: make([]cp.Vector, 0),
-		thrust:   cp.Vector{},
 
-		machine: NewMachine(),
+		machine: NewMachine(hull.Memory),
 	}
 	// connect machine state interface
 	b.machine.state = b
 	// create shape
-	b.Shape = cp.NewCircle(b.Body, 8, cp.Vector{})
+	b.Shape = cp.NewCircle(b.Body, b.radius, cp.Vector{})
 	b.Shape.SetElasticity(0)
 	b.Shape.SetFriction(botFrictionCoeff)
 	b.Shape.UserData = b
 	b.Shape.Filter.Categories = SHAPE_CATEGORY_BOT
+	b.Shape.SetCollisionType(COLLISION_TYPE_BOT)
 	sp.AddShape(b.Shape)
 
 	b.Body.UserData = b
@@ -100,32 +178,210 @@ func (b *Bot) CenterOfGravity() cp.Vector {
 	return b.Body.CenterOfGravity()
 }
 
+// setMass sets the mass of the bot and updates the
+// moment of inertia accordingly
+func (b *Bot) setMass(m float64) {
+	b.Body.SetMass(m)
+	b.Body.SetMoment(cp.MomentForCircle(m, 0, b.radius, cp.Vector{}))
+}
+
+// burn converts mass to energy through the Leonhard reactor.
+//
+// Returns the fraction (0..1) of the requested energy
+// that could be provided.
+func (b *Bot) burn(energy float64) float64 {
+	if energy <= 0 {
+		return 1
+	}
+	mass := energy / b.leonhardEfficiency()
+	available := b.Mass() - botMinMass
+	if available <= 0 {
+		return 0
+	}
+	frac := 1.0
+	if mass > available {
+		frac = available / mass
+		mass = available
+	}
+	b.setMass(b.Mass() - mass)
+	return frac
+}
+
+// Destroy marks the bot as destroyed.
+func (b *Bot) Destroy() {
+	b.destroyed = true
+}
+
+// Alive returns false if the bot has been destroyed
+// or ran out of energy.
+func (b *Bot) Alive() bool {
+	return !b.destroyed && b.energy() > 0
+}
+
+// remove takes the bot out of the space and releases
+// its resources
+func (b *Bot) remove() {
+	b.space.RemoveShape(b.Shape)
+	b.space.RemoveBody(b.Body)
+	b.machine.Destroy()
+}
+
+// hit registers an impact with energy and applies
+// the resulting damage
+func (b *Bot) hit(energy float64) {
+	b.impact += energy
+	if energy <= collisionDamageThreshold {
+		return
+	}
+	damage := (energy - collisionDamageThreshold) * collisionDamage
+	b.setMass(math.Max(b.Mass()-damage, botMinMass))
+}
+
 func (b *Bot) FrameReset() {
 	b.impulses = b.impulses[:0]
 }
 
+// observe takes the snapshot of the bot for the next
+// cycle. Impacts are reset.
+func (b *Bot) observe(scan *scanIndex) {
+	b.snapshot = BotSnapshot{
+		ID:       b.id,
+		Velocity: b.Velocity(),
+		Energy:   b.energy(),
+		Heading:  b.heading(),
+		Impact:   int16(math.Min(math.Round(b.impact/impactSensorScale), math.MaxInt16)),
+		scan:     scan,
+	}
+	b.impact = 0
+}
+
+// visible returns the shapes in the scanner's FOV.
+//
+// Scanning is expensive, so it only runs for machines that
+// read the scanner. The world does not change while the
+// machines run, the scan sees the world of the snapshot.
+func (b *Bot) visible() []*cp.Shape {
+	if !b.snapshot.scanned {
+		b.snapshot.Visible = b.inFOV(b.snapshot.scan)
+		b.snapshot.scanned = true
+	}
+	return b.snapshot.Visible
+}
+
 func (b *Bot) Reset() {
-	b.thrust.X, b.thrust.Y = 0, 0
+	b.intent = Intent{}
 }
 
 func (b *Bot) X() int16 {
-	return int16(math.Round(b.Velocity().X))
+	return int16(math.Round(b.snapshot.Velocity.X))
 }
 
 func (b *Bot) Y() int16 {
-	return int16(math.Round(b.Velocity().Y))
+	return int16(math.Round(b.snapshot.Velocity.Y))
+}
+
+// Heading returns the bot's heading in degrees [0, 360)
+func (b *Bot) Heading() int16 {
+	return b.snapshot.Heading
+}
+
+func (b *Bot) heading() int16 {
+	deg := math.Mod(b.Angle()/math.Pi*180, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return int16(deg) % 360
 }
 
 func (b *Bot) Energy() int16 {
-	return int16(math.Round(b.Mass() * b.leonhardEfficiency()))
+	return b.snapshot.Energy
+}
+
+// energy returns the energy of the bot's mass, heavy bots
+// are clamped to the largest value
+func (b *Bot) energy() int16 {
+	return int16(math.Min(math.Round(b.Mass()*b.leonhardEfficiency()), math.MaxInt16))
+}
+
+// Impact returns the energy of collisions since the last cycle
+func (b *Bot) Impact() int16 {
+	return b.snapshot.Impact
 }
 
 func (b *Bot) ID() int16 {
-	return b.id
+	return b.snapshot.ID
+}
+
+// RemoteID selects the n-th closest object in the scanner's FOV
+// as the remote object.
+//
+// Returns the ID of the remote bot, -1 for objects without ID
+// and 0 if there is no such object.
+func (b *Bot) RemoteID(n int16) int16 {
+	b.remote = nil
+	shapes := b.visible()
+	if n < 0 || int(n) >= len(shapes) {
+		return 0
+	}
+	b.remote = shapes[n]
+	if r, ok := b.remote.UserData.(*Bot); ok {
+		return r.id
+	}
+	return -1
 }
 
-func (b *Bot) RemoteID(int16) int16 {
-	return 1
+// inFOV returns all shapes within range and FOV of the
+// scanner, closest first
+func (b *Bot) inFOV(scan *scanIndex) []*cp.Shape {
+	pos := b.Position()
+	rng := b.scanRange()
+	fov := b.scanFOV() / 360 * math.Pi
+	heading := b.Rotation()
+
+	var shapes []*cp.Shape
+	dists := make(map[*cp.Shape]float64)
+	// in toroidal worlds objects across the bounds are
+	// seen at their images
+	for _, off := range worldOf(b.space).images(pos, rng) {
+		p := pos.Sub(off)
+		scan.query(cp.NewBBForCircle(p, rng), func(s *cp.Shape) {
+			if s == b.Shape {
+				return
+			}
+			info := s.PointQuery(p)
+			if info.Distance > rng {
+				return
+			}
+			if d, ok := dists[s]; ok && d <= info.Distance {
+				return
+			}
+			dir := info.Point.Sub(p)
+			// touching objects are always in view
+			if info.Distance > b.radius+contactRange &&
+				math.Abs(heading.Unrotate(dir).ToAngle()) > fov {
+				return
+			}
+			if _, ok := dists[s]; !ok {
+				shapes = append(shapes, s)
+			}
+			dists[s] = info.Distance
+		})
+	}
+	sort.SliceStable(shapes, func(i, j int) bool {
+		return dists[shapes[i]] < dists[shapes[j]]
+	})
+	// objects made of several shapes are listed once,
+	// by their closest shape
+	seen := make(map[interface{}]bool)
+	objects := shapes[:0]
+	for _, s := range shapes {
+		if s.UserData != nil && seen[s.UserData] {
+			continue
+		}
+		seen[s.UserData] = true
+		objects = append(objects, s)
+	}
+	return objects
 }
 
 func (b *Bot) Scan(x, y int16) (int16, int16) {
@@ -134,35 +390,165 @@ func (b *Bot) Scan(x, y int16) (int16, int16) {
 
 func (b *Bot) Thrust(x, y int16) {
 	v := cp.Vector{X: float64(x), Y: float64(y)}
-	b.thrust = b.thrust.Add(v)
+	b.intent.Thrust = b.intent.Thrust.Add(v)
 }
 
+// Turn changes the angular velocity by a degrees per second
 func (b *Bot) Turn(a int16) {
-	angle := float64(a) / 180 * math.Pi
-	b.angle += angle
+	b.intent.Turn += float64(a) / 180 * math.Pi
+}
+
+// Give transfers energy to the remote object
+func (b *Bot) Give(energy int16) {
+	b.intent.Transfer += float64(energy)
+}
+
+// Take transfers energy from the remote object
+func (b *Bot) Take(energy int16) {
+	b.intent.Transfer -= float64(energy)
+}
+
+// touchingRemote returns the shape of the remote object
+// if it still exists and is touching the bot
+func (b *Bot) touchingRemote() *cp.Shape {
+	if b.remote == nil {
+		return nil
+	}
+	if !b.space.ContainsShape(b.remote) {
+		b.remote = nil
+		return nil
+	}
+	if info, _ := b.query(b.remote); info.Distance > b.radius+contactRange {
+		return nil
+	}
+	return b.remote
+}
+
+// query returns the closest point on s to the bot, and the
+// offset of the image of s it is on
+func (b *Bot) query(s *cp.Shape) (cp.PointQueryInfo, cp.Vector) {
+	return worldOf(b.space).pointQuery(s, b.Position())
+}
+
+// commitTransfer applies the energy transfer with the
+// remote object.
+//
+// A transfer is only possible if the remote object is
+// touching the bot. The transferred mass is converted by
+// the bot's Leonhard reactor, only the efficient part
+// arrives.
+func (b *Bot) commitTransfer() {
+	if b.intent.Transfer == 0 {
+		return
+	}
+	s := b.touchingRemote()
+	if s == nil {
+		return
+	}
+	remote, ok := s.UserData.(massive)
+	if !ok {
+		return
+	}
+	var from, to massive = b, remote
+	amount := b.intent.Transfer
+	if amount < 0 {
+		from, to, amount = remote, b, -amount
+	}
+	amount = math.Min(amount, from.Mass()-botMinMass)
+	if amount <= 0 {
+		return
+	}
+	from.setMass(from.Mass() - amount)
+	to.setMass(to.Mass() + amount*b.leonhardEfficiency())
+}
+
+// Dock connects the bot to the touching remote bot
+func (b *Bot) Dock() {
+	b.intent.Dock = true
+}
+
+// Undock releases the connection to the remote bot
+func (b *Bot) Undock() {
+	b.intent.Undock = true
 }
 
+// AttachTether attaches a tether to the remote asteroid
+func (b *Bot) AttachTether() {
+	b.intent.Tether = true
+}
+
+// ReleaseTether releases the tether
+func (b *Bot) ReleaseTether() {
+	b.intent.Release = true
+}
+
+// Mine mines the remote asteroid with strength
 func (b *Bot) Mine(strength int16) {
+	b.intent.Mining += float64(strength)
+}
+
+// commitMine mines the remote asteroid.
+//
+// The asteroid must be touching the bot. The strength is
+// limited by the miner and mining costs energy. What the bot
+// gains depends on the ore where it touches the asteroid.
+func (b *Bot) commitMine() {
+	if b.intent.Mining <= 0 {
+		return
+	}
+	s := b.touchingRemote()
+	if s == nil {
+		return
+	}
+	a, ok := s.UserData.(*Asteroid)
+	if !ok {
+		return
+	}
+	strength := math.Min(b.intent.Mining, b.mineStrength())
+	strength *= b.burn(strength * mineCost)
+	info, _ := b.query(s)
+	point := info.Point
+	b.setMass(b.Mass() + a.mine(a.WorldToLocal(point), strength))
 }
 
 func (b *Bot) Reproduce(energy int16) {
 }
 
 func (b *Bot) Impulse(strength int16) {
-	v := cp.ForAngle(b.angle)
-	v = v.Mult(float64(strength))
-	b.thrust = b.thrust.Add(v)
+	b.intent.Impulse += float64(strength)
 }
 
+// Execute applies the bot's intended thrust and turn
 func (b *Bot) Execute() {
-	if b.thrust.X != 0 || b.thrust.Y != 0 {
-		// apply thrust
-		v := b.thrust
-		v = v.Clamp(300)
-		b.ApplyImpulseAtLocalPoint(
-			v,
-			b.CenterOfGravity(),
-		)
-		b.impulses = append(b.impulses, v)
+	thrust := b.intent.Thrust.Add(b.Rotation().Mult(b.intent.Impulse))
+	if thrust.X != 0 || thrust.Y != 0 {
+		// translate the commanded thrust steps to a force
+		step := math.Min(thrust.Length(), math.MaxInt16)
+		v := thrust.Normalize().Mult(b.thrustStep(int16(step)))
+		// the energy is provided by the reactor, limit
+		// thrust to what the bot can still burn
+		v = v.Mult(b.burn(v.Length() * impulseCost))
+		if v.X != 0 || v.Y != 0 {
+			b.ApplyImpulseAtLocalPoint(
+				v,
+				b.CenterOfGravity(),
+			)
+			b.impulses = append(b.impulses, v)
+		}
+	}
+	if b.intent.Turn != 0 {
+		// angular impulse necessary for the commanded
+		// change in angular velocity
+		j := b.intent.Turn * b.Body.Moment()
+		max := b.turnImpulse()
+		if j > max {
+			j = max
+		} else if j < -max {
+			j = -max
+		}
+		// the attitude thrusters sit on the hull, the linear impulse
+		// is therefore j / r
+		j *= b.burn(math.Abs(j) / b.radius * impulseCost)
+		b.SetAngularVelocity(b.AngularVelocity() + j/b.Body.Moment())
 	}
 }
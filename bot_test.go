@@ -0,0 +1,349 @@
+package main
+
+import (
+	"image"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrustConsumesMass(t *testing.T) {
+	b := NewBot(cp.NewSpace(), 1, testHull(t))
+
+	b.Reset()
+	b.Thrust(50, 0)
+	b.Execute()
+
+	force := b.thrustStep(50)
+	if !assert.Len(t, b.impulses, 1) {
+		return
+	}
+	assert.InDelta(t, force, b.impulses[0].X, 1e-9)
+	burnt := force * impulseCost / b.leonhardEfficiency()
+	assert.InDelta(t, b.hull.Mass-burnt, b.Mass(), 1e-9)
+}
+
+func TestTurnConsumesEnergy(t *testing.T) {
+	b := NewBot(cp.NewSpace(), 1, testHull(t))
+
+	b.Reset()
+	b.Turn(90)
+	j := math.Min(math.Pi/2*b.Body.Moment(), b.turnImpulse())
+	b.Execute()
+
+	assert.InDelta(t, j/b.Body.Moment(), b.AngularVelocity(), 1e-6)
+	burnt := j / b.radius * impulseCost / b.leonhardEfficiency()
+	assert.InDelta(t, b.hull.Mass-burnt, b.Mass(), 1e-9)
+}
+
+func TestThrustIsLimitedByEnergy(t *testing.T) {
+	b := NewBot(cp.NewSpace(), 1, testHull(t))
+	b.setMass(botMinMass + .01)
+
+	b.Reset()
+	b.Thrust(0, 500)
+	b.Execute()
+
+	if !assert.Len(t, b.impulses, 1) {
+		return
+	}
+	assert.Less(t, b.impulses[0].Length(), b.thrustStep(500))
+	assert.InDelta(t, botMinMass, b.Mass(), 1e-9)
+
+	// out of energy, no more thrust
+	b.FrameReset()
+	b.Reset()
+	b.Thrust(0, 500)
+	b.Execute()
+	assert.Len(t, b.impulses, 0)
+}
+
+func TestDeadBotsAreRemoved(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+
+	var events []Event
+	g.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+
+	starved := NewBot(g.space, 1, testHull(t))
+	starved.setMass(botMinMass)
+	destroyed := NewBot(g.space, 2, testHull(t))
+	destroyed.Destroy()
+	alive := NewBot(g.space, 3, testHull(t))
+	g.bots = append(g.bots, starved, destroyed, alive)
+
+	g.reap()
+
+	if !assert.Equal(t, []*Bot{alive}, g.bots) {
+		return
+	}
+	assert.False(t, g.space.ContainsBody(starved.Body))
+	assert.False(t, g.space.ContainsShape(destroyed.Shape))
+	assert.Nil(t, starved.machine.stack)
+	if !assert.Len(t, events, 2) {
+		return
+	}
+	assert.Equal(t, EventBotDeath, events[0].Type)
+	assert.Equal(t, int16(1), events[0].BotID)
+	assert.Equal(t, int16(2), events[1].BotID)
+	// only the destroyed bot had enough mass left for debris
+	if !assert.Len(t, g.debris, 1) {
+		return
+	}
+	assert.Equal(t, destroyed.hull.Mass, g.debris[0].Mass())
+}
+
+func TestHeavyBotsAreAlive(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+
+	heavy := NewBot(g.space, 1, testHull(t))
+	heavy.setMass(60000)
+	g.bots = append(g.bots, heavy)
+
+	assert.Equal(t, int16(math.MaxInt16), heavy.energy())
+	assert.True(t, heavy.Alive())
+	g.reap()
+	assert.Equal(t, []*Bot{heavy}, g.bots)
+}
+
+func TestDebrisDecays(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+
+	d := NewDebris(g.space, 2, cp.Vector{}, cp.Vector{})
+	g.debris = append(g.debris, d)
+
+	g.reap()
+	assert.Less(t, d.Mass(), 2.)
+	assert.Equal(t, d.Radius(), NewDebris(cp.NewSpace(), 2, cp.Vector{}, cp.Vector{}).Radius())
+
+	for i := 0; i < 1000 && len(g.debris) > 0; i++ {
+		g.reap()
+	}
+	assert.Empty(t, g.debris)
+	assert.False(t, g.space.ContainsBody(d.Body))
+}
+
+func TestCollisionDamagesBots(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+
+	var events []Event
+	g.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+
+	a := NewBot(g.space, 1, testHull(t))
+	a.SetPosition(cp.Vector{X: 0, Y: 0})
+	a.SetVelocity(200, 0)
+	b := NewBot(g.space, 2, testHull(t))
+	b.SetPosition(cp.Vector{X: 30, Y: 0})
+	b.SetVelocity(-200, 0)
+
+	for i := 0; i < 10; i++ {
+		g.space.Step(1. / 60)
+	}
+
+	if !assert.Len(t, events, 2) {
+		return
+	}
+	assert.Equal(t, EventBotCollision, events[0].Type)
+	assert.Greater(t, events[0].Energy, float64(collisionDamageThreshold))
+	assert.Less(t, a.Mass(), a.hull.Mass)
+	assert.Less(t, b.Mass(), b.hull.Mass)
+	a.observe(g.newScanIndex())
+	assert.Greater(t, a.Impact(), int16(0))
+}
+
+func TestEnergyTransferBetweenTouchingBots(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	g.metabolism = Metabolism{}
+
+	a := NewBot(g.space, 1, testHull(t))
+	b := NewBot(g.space, 2, testHull(t))
+	b.SetPosition(cp.Vector{X: a.radius + b.radius + 1, Y: 0})
+	far := NewBot(g.space, 3, testHull(t))
+	far.SetPosition(cp.Vector{X: 100, Y: 0})
+	g.bots = append(g.bots, a, b, far)
+	// update the spatial index
+	g.space.Step(1. / 60)
+
+	a.observe(g.newScanIndex())
+	if !assert.Equal(t, int16(2), a.RemoteID(0)) {
+		return
+	}
+	a.Give(10)
+	g.commit()
+	assert.InDelta(t, a.hull.Mass-10, a.Mass(), 1e-9)
+	assert.InDelta(t, b.hull.Mass+10*a.leonhardEfficiency(), b.Mass(), 1e-9)
+
+	// in view, but not in contact
+	a.Reset()
+	if !assert.Equal(t, int16(3), a.RemoteID(1)) {
+		return
+	}
+	a.Take(10)
+	g.commit()
+	assert.Equal(t, far.hull.Mass, far.Mass())
+}
+
+func TestMetabolism(t *testing.T) {
+	code := `BEGIN EV
+	// comments are free
+	PSH CON 1
+END
+BEGIN EX
+	PSH CON 2
+	IMP
+END
+`
+	p := NewParser(strings.NewReader(code))
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	b := NewBot(cp.NewSpace(), 1, testHull(t))
+	b.machine.program = program
+
+	m := Metabolism{Base: 1, Instruction: .1, Component: .01}
+	assert.InDelta(t, 1+3*.1+float64(b.hull.Components())*.01, m.Cost(b), 1e-9)
+
+	b.burn(m.Cost(b))
+	assert.InDelta(t, b.hull.Mass-m.Cost(b)/b.leonhardEfficiency(), b.Mass(), 1e-9)
+}
+
+func TestDockedBotsMoveTogether(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	g.metabolism = Metabolism{}
+
+	a := NewBot(g.space, 1, testHull(t))
+	b := NewBot(g.space, 2, testHull(t))
+	b.SetPosition(cp.Vector{X: a.radius + b.radius + 1, Y: 0})
+	g.bots = append(g.bots, a, b)
+	g.space.Step(1. / 60)
+
+	a.observe(g.newScanIndex())
+	a.RemoteID(0)
+	a.Dock()
+	g.commit()
+	if !assert.Len(t, g.docks, 1) {
+		return
+	}
+
+	a.ApplyImpulseAtLocalPoint(cp.Vector{X: 0, Y: 1000}, cp.Vector{})
+	for i := 0; i < 60; i++ {
+		g.space.Step(1. / 60)
+	}
+	assert.InDelta(t, a.radius+b.radius+1, a.Position().Distance(b.Position()), .1)
+	assert.InDelta(t, a.AngularVelocity(), b.AngularVelocity(), 1e-3)
+	assert.Greater(t, b.Position().Y, 1.0)
+
+	// dead bots are released
+	b.Destroy()
+	g.reap()
+	assert.Len(t, g.docks, 0)
+	g.space.EachConstraint(func(c *cp.Constraint) {
+		t.Errorf("unexpected constraint %v", c)
+	})
+}
+
+func TestTetherSnaps(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	g.metabolism = Metabolism{}
+
+	var events []Event
+	g.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+
+	a := NewAsteroid(g.space, image.Rect(0, 0, 100, 100))
+	a.generate(1)
+	b := NewBot(g.space, 1, testHull(t))
+	b.SetPosition(a.Position().Add(cp.Vector{X: -200, Y: 0}))
+	g.bots = append(g.bots, b)
+	g.space.Step(1. / 60)
+
+	g.attach(b, a, a.LocalToWorld(a.Body.CenterOfGravity()))
+	if !assert.NotNil(t, b.tether) {
+		return
+	}
+
+	// a gentle tug does not break the tether
+	b.ApplyImpulseAtLocalPoint(cp.Vector{X: -10, Y: 0}, cp.Vector{})
+	for i := 0; i < 10; i++ {
+		g.space.Step(1. / 60)
+	}
+	if !assert.NotNil(t, b.tether) {
+		return
+	}
+
+	b.ApplyImpulseAtLocalPoint(cp.Vector{X: -100000, Y: 0}, cp.Vector{})
+	g.space.Step(1. / 60)
+	assert.Nil(t, b.tether)
+	assert.Len(t, g.tethers, 0)
+	if !assert.Len(t, events, 1) {
+		return
+	}
+	assert.Equal(t, EventTetherBreak, events[0].Type)
+}
+
+func TestMiningYieldDependsOnOre(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	g.metabolism = Metabolism{}
+
+	a := NewAsteroid(g.space, image.Rect(0, 0, 100, 100))
+	a.Composition = Composition{
+		Matrix: ResourceSilicate,
+		Deposits: []Deposit{
+			{Resource: ResourcePlatinum, Center: cp.Vector{X: 0, Y: 50}, Radius: 20, Richness: 1},
+		},
+	}
+	a.density = a.Composition.Density()
+	a.build([]cp.Vector{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}})
+	g.asteroids = append(g.asteroids, a)
+
+	hull := testHull(t)
+	ore := NewBot(g.space, 1, hull)
+	ore.SetPosition(cp.Vector{X: -hull.Radius - 1, Y: 50})
+	rock := NewBot(g.space, 2, hull)
+	rock.SetPosition(cp.Vector{X: 100 + hull.Radius + 1, Y: 50})
+	g.bots = append(g.bots, ore, rock)
+	g.space.Step(1. / 60)
+
+	mass := a.Mass()
+	var gains []float64
+	for _, b := range g.bots {
+		a.Body.EachShape(func(s *cp.Shape) {
+			b.remote = s
+		})
+		before := b.Mass()
+		b.Mine(10)
+		b.commitMine()
+		gains = append(gains, b.Mass()-before)
+	}
+
+	strength := hull.Miner.Strength
+	cost := strength * mineCost / hull.Reactor.Efficiency
+	assert.InDelta(t, strength*1-cost, gains[0], 1e-9)
+	assert.InDelta(t, strength*.2-cost, gains[1], 1e-9)
+	assert.InDelta(t, mass-2*strength, a.Mass(), 1e-6)
+}
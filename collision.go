@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+
+	"github.com/jakecoffman/cp"
+)
+
+// The collision types for chipmunk
+// see https://chipmunk-physics.net/release/ChipmunkLatest-Docs/#CollisionCallbacks
+const (
+	COLLISION_TYPE_BOT cp.CollisionType = iota + 1
+	COLLISION_TYPE_ASTEROID
+	COLLISION_TYPE_WELL
+)
+
+const (
+	// collisionDamageThreshold is the impact energy a bot's
+	// hull can take without being damaged
+	collisionDamageThreshold = 45000
+	// collisionDamage is the mass lost per unit of impact
+	// energy above the threshold
+	collisionDamage = 2e-5
+	// impactSensorScale scales impact energy for the
+	// bot's sensors
+	impactSensorScale = 100
+)
+
+func (g *Game) initCollisions() {
+	h := g.space.NewCollisionHandler(COLLISION_TYPE_BOT, COLLISION_TYPE_BOT)
+	h.PostSolveFunc = g.collide
+	h = g.space.NewCollisionHandler(COLLISION_TYPE_BOT, COLLISION_TYPE_ASTEROID)
+	h.PostSolveFunc = g.collide
+	h = g.space.NewCollisionHandler(COLLISION_TYPE_ASTEROID, COLLISION_TYPE_ASTEROID)
+	h.PostSolveFunc = g.collide
+	h = g.space.NewCollisionHandler(COLLISION_TYPE_BOT, COLLISION_TYPE_WELL)
+	h.PostSolveFunc = g.collide
+	h = g.space.NewCollisionHandler(COLLISION_TYPE_ASTEROID, COLLISION_TYPE_WELL)
+	h.PostSolveFunc = g.collide
+}
+
+// collide handles the impact of two shapes.
+func (g *Game) collide(arb *cp.Arbiter, _ *cp.Space, _ interface{}) {
+	if !arb.IsFirstContact() {
+		return
+	}
+	a, b := arb.Bodies()
+	j := arb.TotalImpulse()
+	energy := impactEnergy(j, a, b)
+
+	sa, sb := arb.Shapes()
+	for _, s := range []*cp.Shape{sa, sb} {
+		if ast, ok := s.UserData.(*Asteroid); ok {
+			g.strike(ast, arb.ContactPointSet().Points[0].PointA, j)
+			continue
+		}
+		bot, ok := s.UserData.(*Bot)
+		if !ok {
+			continue
+		}
+		bot.hit(energy)
+		g.emit(Event{
+			Type:     EventBotCollision,
+			BotID:    bot.id,
+			Position: bot.Position(),
+			Energy:   energy,
+		})
+	}
+}
+
+// impactEnergy returns the kinetic energy dissipated by an
+// inelastic impact with impulse j between a and b
+func impactEnergy(j cp.Vector, a, b *cp.Body) float64 {
+	ma, mb := a.Mass(), b.Mass()
+	var mu float64
+	switch {
+	case math.IsInf(ma, 1):
+		mu = mb
+	case math.IsInf(mb, 1):
+		mu = ma
+	default:
+		mu = ma * mb / (ma + mb)
+	}
+	return j.LengthSq() / (2 * mu)
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/jakecoffman/cp"
+)
+
+const (
+	// ResourceSilicate is common rock, hard to process
+	ResourceSilicate Resource = iota
+	// ResourceIce is frozen volatiles, light and easy to process
+	ResourceIce
+	// ResourceIron is metal, dense
+	ResourceIron
+	// ResourcePlatinum is rare metal only found in deposits
+	ResourcePlatinum
+)
+
+const (
+	// maxDeposits is the maximum number of ore deposits
+	// in an asteroid
+	maxDeposits = 4
+	// mineCost is the energy it costs to mine one unit of mass
+	mineCost = .05
+)
+
+type (
+	// Resource is a type of material asteroids are made of
+	Resource uint8
+
+	// ResourceType describes the properties of a resource
+	ResourceType struct {
+		Name    string
+		Density float64
+		// Yield (0..1) is the fraction of the mined mass a
+		// bot gains
+		Yield float64
+		// Abundance is the relative probability of the
+		// resource being the matrix of an asteroid
+		Abundance float64
+	}
+
+	// Deposit is a concentration of ore in an asteroid.
+	// Its richness falls off linearly towards its radius.
+	Deposit struct {
+		Resource Resource
+		// Center in body coordinates
+		Center cp.Vector
+		Radius float64
+		// Richness (0..1) in the center of the deposit
+		Richness float64
+	}
+
+	// Composition describes what an asteroid is made of
+	Composition struct {
+		// Matrix is the resource that makes up the bulk of
+		// the asteroid
+		Matrix   Resource
+		Deposits []Deposit
+	}
+)
+
+var resourceTypes = [...]ResourceType{
+	ResourceSilicate: {Name: "silicate", Density: 10, Yield: .2, Abundance: .6},
+	ResourceIce:      {Name: "ice", Density: 5, Yield: .6, Abundance: .25},
+	ResourceIron:     {Name: "iron", Density: 20, Yield: .4, Abundance: .15},
+	ResourcePlatinum: {Name: "platinum", Density: 30, Yield: 1},
+}
+
+func (r Resource) Type() ResourceType {
+	return resourceTypes[r]
+}
+
+func (r Resource) String() string {
+	return r.Type().Name
+}
+
+// NewComposition generates the composition of the asteroid
+// with outline from seed.
+//
+// Deposits are placed inside the outline.
+func NewComposition(seed int64, outline []cp.Vector) Composition {
+	rnd := rand.New(rand.NewSource(seed))
+
+	var c Composition
+	var total float64
+	for _, t := range resourceTypes {
+		total += t.Abundance
+	}
+	pick := rnd.Float64() * total
+	for r, t := range resourceTypes {
+		pick -= t.Abundance
+		if pick < 0 {
+			c.Matrix = Resource(r)
+			break
+		}
+	}
+
+	bounds := polygonBounds(outline)
+	size := math.Min(float64(bounds.Dx()), float64(bounds.Dy()))
+	n := rnd.Intn(maxDeposits + 1)
+	for i := 0; i < n; i++ {
+		center, ok := randomPointInPolygon(rnd, outline)
+		if !ok {
+			break
+		}
+		c.Deposits = append(c.Deposits, Deposit{
+			// deposits are ore, never silicate
+			Resource: Resource(1 + rnd.Intn(len(resourceTypes)-1)),
+			Center:   center,
+			Radius:   size * (.1 + rnd.Float64()*.2),
+			Richness: .3 + rnd.Float64()*.7,
+		})
+	}
+	return c
+}
+
+// Density returns the density of the asteroid
+func (c Composition) Density() float64 {
+	return c.Matrix.Type().Density
+}
+
+// translate moves all deposits by d
+func (c Composition) translate(d cp.Vector) Composition {
+	deposits := make([]Deposit, len(c.Deposits))
+	for i, dep := range c.Deposits {
+		dep.Center = dep.Center.Add(d)
+		deposits[i] = dep
+	}
+	c.Deposits = deposits
+	return c
+}
+
+// Ore returns the resource at point (body coordinates) and
+// its richness
+func (c Composition) Ore(point cp.Vector) (Resource, float64) {
+	res, richness := c.Matrix, 0.0
+	for _, d := range c.Deposits {
+		dist := d.Center.Distance(point)
+		if dist >= d.Radius {
+			continue
+		}
+		r := d.Richness * (1 - dist/d.Radius)
+		if r > richness {
+			res, richness = d.Resource, r
+		}
+	}
+	return res, richness
+}
+
+// Yield returns the fraction of the mass mined at point
+// (body coordinates) that is gained.
+//
+// Outside of deposits the yield is that of the matrix.
+// Inside, it rises with richness towards the yield of
+// the ore.
+func (c Composition) Yield(point cp.Vector) float64 {
+	matrix := c.Matrix.Type().Yield
+	res, richness := c.Ore(point)
+	return math.Max(matrix, matrix+(res.Type().Yield-matrix)*richness)
+}
+
+// randomPointInPolygon picks a random point inside the
+// polygon verts by rejection sampling
+func randomPointInPolygon(rnd *rand.Rand, verts []cp.Vector) (cp.Vector, bool) {
+	bb := cp.NewBBForCircle(verts[0], 0)
+	for _, v := range verts {
+		bb = bb.Expand(v)
+	}
+	for i := 0; i < 100; i++ {
+		p := cp.Vector{
+			X: bb.L + rnd.Float64()*(bb.R-bb.L),
+			Y: bb.B + rnd.Float64()*(bb.T-bb.B),
+		}
+		if pointInPolygon(p, verts) {
+			return p, true
+		}
+	}
+	return cp.Vector{}, false
+}
+
+// pointInPolygon returns true if p is inside the simple
+// polygon verts
+func pointInPolygon(p cp.Vector, verts []cp.Vector) bool {
+	inside := false
+	n := len(verts)
+	for i, a := range verts {
+		b := verts[(i+n-1)%n]
+		if (a.Y > p.Y) != (b.Y > p.Y) &&
+			p.X < a.X+(p.Y-a.Y)/(b.Y-a.Y)*(b.X-a.X) {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math"
+
+	"github.com/jakecoffman/cp"
+)
+
+const (
+	debrisFrictionCoeff = 0.6
+	// debrisMinMass is the minimum mass a remnant must have
+	// to be left behind as debris
+	debrisMinMass = 1.0
+	// debrisDensity determines the radius of debris by mass
+	debrisDensity = 1.0
+	// debrisDecay is the fraction of its mass debris loses
+	// per cycle
+	debrisDecay = 0.001
+)
+
+type (
+	// massive is implemented by all objects energy can be
+	// transferred to or from
+	massive interface {
+		Mass() float64
+		setMass(float64)
+	}
+
+	// Debris is a passive lump of mass, e.g. what is left
+	// over after a bot died.
+	Debris struct {
+		*cp.Body
+		*cp.Shape
+
+		space *cp.Space
+	}
+)
+
+func NewDebris(sp *cp.Space, mass float64, pos, vel cp.Vector) *Debris {
+	return newDebris(sp, mass, math.Sqrt(mass/debrisDensity/math.Pi), pos, vel)
+}
+
+// newDebris creates debris of radius. Debris keeps its
+// radius when it loses mass.
+func newDebris(sp *cp.Space, mass, radius float64, pos, vel cp.Vector) *Debris {
+	d := &Debris{
+		space: sp,
+		Body:  sp.AddBody(cp.NewBody(mass, cp.MomentForCircle(mass, 0, radius, cp.Vector{}))),
+	}
+	d.Body.SetPosition(pos)
+	d.Body.SetVelocityVector(vel)
+	d.Body.UserData = d
+
+	d.Shape = cp.NewCircle(d.Body, radius, cp.Vector{})
+	d.Shape.SetFriction(debrisFrictionCoeff)
+	d.Shape.UserData = d
+	d.Shape.Filter.Categories = SHAPE_CATEGORY_DEBRIS
+	sp.AddShape(d.Shape)
+	return d
+}
+
+func (d *Debris) Mass() float64 {
+	return d.Body.Mass()
+}
+
+func (d *Debris) setMass(m float64) {
+	d.Body.SetMass(m)
+	d.Body.SetMoment(cp.MomentForCircle(m, 0, d.Radius(), cp.Vector{}))
+}
+
+// decay lets the debris lose a part of its mass
+func (d *Debris) decay() {
+	d.setMass(d.Mass() * (1 - debrisDecay))
+}
+
+// remove takes the debris out of the space
+func (d *Debris) remove() {
+	d.space.RemoveShape(d.Shape)
+	d.space.RemoveBody(d.Body)
+}
+
+func (d *Debris) Radius() float64 {
+	return d.Shape.Class.(*cp.Circle).Radius()
+}
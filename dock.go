@@ -0,0 +1,113 @@
+package main
+
+import (
+	"github.com/jakecoffman/cp"
+)
+
+type (
+	// DockJoint is a rigid connection between two bots.
+	//
+	// Docked bots move as one assembly. The pivot joint
+	// keeps them together, the gear joint locks their
+	// relative rotation.
+	DockJoint struct {
+		a, b *Bot
+
+		pivot *cp.Constraint
+		gear  *cp.Constraint
+		// phase is the locked angle between b and a
+		phase float64
+	}
+)
+
+// commitDock applies the docking requests of b
+func (g *Game) commitDock(b *Bot) {
+	if !b.intent.Dock && !b.intent.Undock {
+		return
+	}
+	if b.intent.Undock && b.remote != nil {
+		if r, ok := b.remote.UserData.(*Bot); ok {
+			g.undock(b, r)
+		}
+	}
+	if !b.intent.Dock {
+		return
+	}
+	s := b.touchingRemote()
+	if s == nil {
+		return
+	}
+	r, ok := s.UserData.(*Bot)
+	if !ok {
+		return
+	}
+	// docks do not reach across the bounds of the world
+	if _, offset := b.query(s); offset != (cp.Vector{}) {
+		return
+	}
+	g.dock(b, r)
+}
+
+// dock connects a and b at their point of contact
+func (g *Game) dock(a, b *Bot) {
+	if g.findDock(a, b) >= 0 {
+		return
+	}
+	pa, pb := a.Position(), b.Position()
+	// contact point on the line between both centers
+	pivot := pa.Lerp(pb, a.radius/(a.radius+b.radius))
+	g.join(a, b, a.WorldToLocal(pivot), b.WorldToLocal(pivot), b.Angle()-a.Angle())
+}
+
+// join connects a and b at the anchors (body coordinates)
+// and locks their relative rotation at phase
+func (g *Game) join(a, b *Bot, anchorA, anchorB cp.Vector, phase float64) {
+	d := &DockJoint{
+		a:     a,
+		b:     b,
+		pivot: cp.NewPivotJoint2(a.Body, b.Body, anchorA, anchorB),
+		gear:  cp.NewGearJoint(a.Body, b.Body, phase, 1),
+		phase: phase,
+	}
+	d.pivot.SetCollideBodies(false)
+	d.gear.SetCollideBodies(false)
+	g.space.AddConstraint(d.pivot)
+	g.space.AddConstraint(d.gear)
+	g.docks = append(g.docks, d)
+}
+
+// undock releases the connection between a and b
+func (g *Game) undock(a, b *Bot) {
+	i := g.findDock(a, b)
+	if i < 0 {
+		return
+	}
+	g.removeDock(i)
+}
+
+// undockAll releases all connections of b
+func (g *Game) undockAll(b *Bot) {
+	for i := len(g.docks) - 1; i >= 0; i-- {
+		if g.docks[i].a == b || g.docks[i].b == b {
+			g.removeDock(i)
+		}
+	}
+}
+
+func (g *Game) findDock(a, b *Bot) int {
+	for i, d := range g.docks {
+		if (d.a == a && d.b == b) || (d.a == b && d.b == a) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (g *Game) removeDock(i int) {
+	d := g.docks[i]
+	g.space.RemoveConstraint(d.pivot)
+	g.space.RemoveConstraint(d.gear)
+	copy(g.docks[i:], g.docks[i+1:])
+	g.docks[len(g.docks)-1] = nil
+	g.docks = g.docks[:len(g.docks)-1]
+}
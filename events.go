@@ -0,0 +1,78 @@
+package main
+
+import (
+	"github.com/jakecoffman/cp"
+)
+
+const (
+	// EventBotDeath is emitted when a bot is removed from the game
+	EventBotDeath EventType = iota + 1
+	// EventBotCollision is emitted when a bot hits a bot
+	// or an asteroid
+	EventBotCollision
+	// EventTetherBreak is emitted when a tether snaps
+	EventTetherBreak
+	// EventAsteroidFracture is emitted when an asteroid
+	// splits into fragments
+	EventAsteroidFracture
+	// EventTick is emitted after each tick
+	EventTick
+	// EventRestore is emitted when the world was restored
+	// from a save, see Game.Restore
+	EventRestore
+	// EventRewind is emitted when the game was set back in
+	// time, see Game.Rewind
+	EventRewind
+)
+
+type (
+	EventType int
+
+	// Event is emitted by the game for everything
+	// worth knowing outside of the simulation, such as
+	// statistics, logging or the UI.
+	Event struct {
+		Type EventType
+		Step int64
+
+		BotID    int16
+		Position cp.Vector
+		// Energy of the impact for collisions
+		Energy float64
+	}
+
+	EventFunc func(Event)
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventBotDeath:
+		return "bot_death"
+	case EventBotCollision:
+		return "bot_collision"
+	case EventTetherBreak:
+		return "tether_break"
+	case EventAsteroidFracture:
+		return "asteroid_fracture"
+	case EventTick:
+		return "tick"
+	case EventRestore:
+		return "restore"
+	case EventRewind:
+		return "rewind"
+	default:
+		return "unknown"
+	}
+}
+
+// Subscribe registers f to be called for every event
+func (g *Game) Subscribe(f EventFunc) {
+	g.listeners = append(g.listeners, f)
+}
+
+func (g *Game) emit(e Event) {
+	e.Step = g.step
+	for _, f := range g.listeners {
+		f(e)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"image"
+	"math"
+	"math/rand"
+
+	"github.com/jakecoffman/cp"
+)
+
+// fieldAttemptsPerAsteroid limits the attempts to find a
+// free spot for each asteroid of a field
+const fieldAttemptsPerAsteroid = 30
+
+type (
+	// AsteroidField fills a region with asteroids
+	AsteroidField struct {
+		Region Region
+		// Count is the target number of asteroids. Fewer
+		// are placed if the region is too crowded.
+		Count int
+		Sizes SizeDistribution
+		// MinSpacing is the minimum gap between asteroids
+		// and any other object at spawn
+		MinSpacing float64
+
+		// Drift is the maximum speed in a random direction
+		Drift float64
+		// Orbit is the gravitational parameter (G*M) of the
+		// body at the region's center. If set, asteroids
+		// orbit the center counter-clockwise.
+		Orbit float64
+		// Spin is the maximum angular velocity in radians
+		// per second
+		Spin float64
+
+		// Generator generates the outlines, the default
+		// generator is used if nil
+		Generator ShapeGenerator
+	}
+
+	// Region is an area asteroids are spawned in
+	Region interface {
+		// Sample returns a random point in the region
+		Sample(rnd *rand.Rand) cp.Vector
+		Center() cp.Vector
+	}
+
+	// RectRegion is a rectangular region
+	RectRegion struct {
+		cp.BB
+	}
+
+	// RingRegion is a ring shaped region, e.g. a belt
+	// around a planet
+	RingRegion struct {
+		Origin       cp.Vector
+		Inner, Outer float64
+	}
+
+	// SizeDistribution determines the size of asteroids
+	SizeDistribution interface {
+		Size(rnd *rand.Rand) float64
+	}
+
+	// PowerLaw distributes sizes between Min and Max with
+	// a density proportional to size^-Alpha. Many small and
+	// few large asteroids for Alpha > 0, uniform for 0.
+	PowerLaw struct {
+		Min, Max, Alpha float64
+	}
+)
+
+func (r RectRegion) Sample(rnd *rand.Rand) cp.Vector {
+	return cp.Vector{
+		X: r.L + rnd.Float64()*(r.R-r.L),
+		Y: r.B + rnd.Float64()*(r.T-r.B),
+	}
+}
+
+func (r RingRegion) Sample(rnd *rand.Rand) cp.Vector {
+	// uniform over the area of the ring
+	inner, outer := r.Inner*r.Inner, r.Outer*r.Outer
+	radius := math.Sqrt(inner + rnd.Float64()*(outer-inner))
+	return r.Origin.Add(cp.ForAngle(rnd.Float64() * 2 * math.Pi).Mult(radius))
+}
+
+func (r RingRegion) Center() cp.Vector {
+	return r.Origin
+}
+
+func (p PowerLaw) Size(rnd *rand.Rand) float64 {
+	u := rnd.Float64()
+	if p.Alpha == 1 {
+		return p.Min * math.Pow(p.Max/p.Min, u)
+	}
+	// inverse transform sampling
+	e := 1 - p.Alpha
+	lo, hi := math.Pow(p.Min, e), math.Pow(p.Max, e)
+	return math.Pow(lo+u*(hi-lo), 1/e)
+}
+
+// Generate spawns the field's asteroids into g, seeded
+// by seed.
+//
+// Asteroids never overlap each other or any object already
+// in the space (see vacant). Returns the spawned asteroids.
+func (f AsteroidField) Generate(g *Game, seed int64) []*Asteroid {
+	rnd := rand.New(rand.NewSource(seed))
+	gen := f.Generator
+	if gen == nil {
+		gen = defaultShapeGenerator
+	}
+	center := f.Region.Center()
+
+	var field []*Asteroid
+	for attempts := f.Count * fieldAttemptsPerAsteroid; len(field) < f.Count && attempts > 0; attempts-- {
+		size := math.Max(1, math.Round(f.Sizes.Size(rnd)))
+		pos := f.Region.Sample(rnd)
+		// the asteroid's outline fits into a square of size,
+		// the circle around it contains any rotation
+		radius := size / math.Sqrt2
+		if !g.vacant(pos, radius+f.MinSpacing) {
+			continue
+		}
+
+		a := NewAsteroid(g.space, image.Rect(0, 0, int(size), int(size)))
+		a.Generator = gen
+		// center the outline on pos
+		half := cp.Vector{X: size / 2, Y: size / 2}
+		a.SetAngle(rnd.Float64() * 2 * math.Pi)
+		a.SetPosition(pos.Sub(cp.ForAngle(a.Angle()).Rotate(half)))
+		a.generate(rnd.Int63())
+
+		vel := cp.ForAngle(rnd.Float64() * 2 * math.Pi).Mult(rnd.Float64() * f.Drift)
+		if r := pos.Sub(center); f.Orbit > 0 && r.Length() > 0 {
+			vel = vel.Add(r.Normalize().Perp().Mult(math.Sqrt(f.Orbit / r.Length())))
+		}
+		a.SetVelocityVector(vel)
+		a.SetAngularVelocity((rnd.Float64()*2 - 1) * f.Spin)
+
+		g.asteroids = append(g.asteroids, a)
+		field = append(field, a)
+	}
+	return field
+}
+
+// vacant returns true if there is no shape within radius
+// of pos.
+//
+// The spatial index is updated when the space is stepped,
+// objects moved since are found at their previous position.
+func (g *Game) vacant(pos cp.Vector, radius float64) bool {
+	vacant := true
+	g.space.BBQuery(cp.NewBBForCircle(pos, radius), cp.SHAPE_FILTER_ALL, func(s *cp.Shape, _ interface{}) {
+		if s.PointQuery(pos).Distance < radius {
+			vacant = false
+		}
+	}, nil)
+	return vacant
+}
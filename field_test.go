@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFields = map[string]AsteroidField{
+	"rect": {
+		Region:     RectRegion{cp.BB{L: -1000, B: -1000, R: 1000, T: 1000}},
+		Count:      60,
+		Sizes:      PowerLaw{Min: 20, Max: 200, Alpha: 2},
+		MinSpacing: 10,
+		Drift:      20,
+		Spin:       .5,
+	},
+	"belt": {
+		Region:     RingRegion{Inner: 1500, Outer: 2000},
+		Count:      60,
+		Sizes:      PowerLaw{Min: 20, Max: 100, Alpha: 1},
+		MinSpacing: 5,
+		Orbit:      1e6,
+	},
+}
+
+func TestAsteroidField(t *testing.T) {
+	for name, f := range testFields {
+		t.Run(name, func(t *testing.T) {
+			g := &Game{}
+			g.init()
+			defer g.Close()
+
+			// an object in the way
+			b := NewBot(g.space, 1, testHull(t))
+			g.bots = append(g.bots, b)
+
+			field := f.Generate(g, 42)
+			assert.Len(t, field, f.Count)
+
+			var shapes []*cp.Shape
+			g.space.EachShape(func(s *cp.Shape) {
+				shapes = append(shapes, s)
+			})
+			for i, s := range shapes {
+				for _, o := range shapes[i+1:] {
+					if s.Body() == o.Body() {
+						continue
+					}
+					assert.Zero(t, cp.ShapesCollide(s, o).Count, "overlap")
+				}
+			}
+
+			// reproducible
+			other := &Game{}
+			other.init()
+			defer other.Close()
+			other.bots = append(other.bots, NewBot(other.space, 1, testHull(t)))
+			for i, a := range f.Generate(other, 42) {
+				assert.Equal(t, field[i].Position(), a.Position())
+				assert.Equal(t, field[i].Velocity(), a.Velocity())
+				assert.Equal(t, field[i].Outline, a.Outline)
+			}
+		})
+	}
+}
+
+func TestFieldOrbit(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+
+	f := testFields["belt"]
+	for _, a := range f.Generate(g, 1) {
+		r := a.Position().Length()
+		assert.GreaterOrEqual(t, r, f.Region.(RingRegion).Inner-100)
+		// orbital velocity is perpendicular to the radius
+		cog := a.LocalToWorld(a.Body.CenterOfGravity())
+		assert.InDelta(t, 0, cog.Normalize().Dot(a.Velocity().Normalize()), .1)
+	}
+}
+
+func TestPowerLaw(t *testing.T) {
+	for _, p := range []PowerLaw{{Min: 10, Max: 100}, {Min: 10, Max: 100, Alpha: 1}, {Min: 10, Max: 100, Alpha: 2.5}} {
+		rnd := rand.New(rand.NewSource(1))
+		for i := 0; i < 1000; i++ {
+			s := p.Size(rnd)
+			assert.GreaterOrEqual(t, s, p.Min)
+			assert.LessOrEqual(t, s, p.Max)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"github.com/jakecoffman/cp"
+)
+
+const (
+	// asteroidFractureDeltaV is the minimum change of velocity
+	// an impact must cause to fracture an asteroid
+	asteroidFractureDeltaV = 2.0
+	// asteroidMinArea is the minimum area of a fragment. Smaller
+	// fragments turn to dust.
+	asteroidMinArea = 200.0
+)
+
+// strike checks if the impact of impulse j at point fractures
+// asteroid a.
+//
+// Fracturing is deferred until after the current physics step.
+func (g *Game) strike(a *Asteroid, point, j cp.Vector) {
+	if j.Length()/a.Mass() < asteroidFractureDeltaV {
+		return
+	}
+	g.space.AddPostStepCallback(func(*cp.Space, interface{}, interface{}) {
+		g.fracture(a, point, j.Normalize())
+	}, a, nil)
+}
+
+// fracture splits asteroid a along the line through point
+// (world coordinates) in direction dir.
+func (g *Game) fracture(a *Asteroid, point, dir cp.Vector) {
+	if len(a.Outline) < 3 || !g.space.ContainsBody(a.Body) {
+		return
+	}
+	// split in body coordinates
+	left, right := splitPolygon(a.Outline, a.WorldToLocal(point), a.Rotation().Unrotate(dir))
+	if len(left) == 0 || len(right) == 0 {
+		return
+	}
+
+	for _, part := range append(left, right...) {
+		if len(part) < 3 {
+			continue
+		}
+		if cp.AreaForPoly(len(part), part, 0) < asteroidMinArea {
+			g.dust(a, part)
+			continue
+		}
+		f := &Asteroid{
+			space:     g.space,
+			Bounds:    polygonBounds(part),
+			Generator: a.Generator,
+			Body:      cp.NewBody(0, 0),
+			// the fragment shares a's body coordinates
+			Composition: a.Composition,
+			density:     a.density,
+		}
+		f.SetAngle(a.Angle())
+		f.SetPosition(a.Position())
+		f.build(part)
+		f.SetVelocityVector(a.VelocityAtWorldPoint(f.LocalToWorld(f.Body.CenterOfGravity())))
+		f.SetAngularVelocity(a.AngularVelocity())
+		g.asteroids = append(g.asteroids, f)
+	}
+	// removing the shapes resets the body's center of
+	// gravity, the fragments need it to inherit the velocity
+	g.removeAsteroid(a)
+	g.emit(Event{
+		Type:     EventAsteroidFracture,
+		Position: point,
+	})
+}
+
+// dust turns a fragment of a into debris
+func (g *Game) dust(a *Asteroid, part []cp.Vector) {
+	area := cp.AreaForPoly(len(part), part, 0)
+	center := a.LocalToWorld(cp.CentroidForPoly(len(part), part))
+	g.debris = append(g.debris, NewDebris(
+		g.space,
+		area*a.density,
+		center,
+		a.VelocityAtWorldPoint(center),
+	))
+}
+
+// removeAsteroid takes a out of the game and releases
+// all tethers attached to it
+func (g *Game) removeAsteroid(a *Asteroid) {
+	for i := len(g.tethers) - 1; i >= 0; i-- {
+		if g.tethers[i].asteroid == a {
+			g.release(g.tethers[i])
+		}
+	}
+	for i, other := range g.asteroids {
+		if other != a {
+			continue
+		}
+		copy(g.asteroids[i:], g.asteroids[i+1:])
+		g.asteroids[len(g.asteroids)-1] = nil
+		g.asteroids = g.asteroids[:len(g.asteroids)-1]
+		break
+	}
+	a.remove()
+}
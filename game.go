@@ -1,12 +1,26 @@
 package main
 
 import (
+	"context"
+	"math"
 	"runtime"
-	"sync"
 
-	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/jakecoffman/cp"
+	"github.com/pkg/errors"
+)
 
-	"github.com/gen2brain/raylib-go/physics"
+const (
+	// timeStep is the simulated time of one tick in seconds
+	timeStep = 1. / 60
+	// defaultSubsteps is the default number of physics
+	// steps per tick
+	defaultSubsteps = 2
+	// maxSpeed is the fastest fast-forward
+	maxSpeed = 64
+	// maxFrameTime limits the time simulated per frame. If
+	// the simulation can not keep up, it slows down instead
+	// of falling further behind.
+	maxFrameTime = .25
 )
 
 // The shape categories for chipmunk
@@ -15,6 +29,9 @@ const (
 	SHAPE_CATEGORY_ANY = 1 << iota
 	SHAPE_CATEGORY_BOT
 	SHAPE_CATEGORY_ASTEROID
+	SHAPE_CATEGORY_DEBRIS
+	SHAPE_CATEGORY_WALL
+	SHAPE_CATEGORY_WELL
 )
 
 type (
@@ -22,58 +39,301 @@ type (
 		paused bool
 		doStep bool
 
+		// cyclesPerTick is the number of machine cycles run
+		// per tick
 		cyclesPerTick int
-		step          int64
+		// substeps is the number of physics steps per tick
+		substeps int
+		// speed is the number of ticks per timeStep of
+		// frame time
+		speed int
+		// accumulator is the frame time not yet simulated
+		accumulator float64
+		// tick and step count the ticks and machine cycles
+		// since the start
+		tick int64
+		step int64
+
+		// seed is the world seed scenarios generate the
+		// world from
+		seed int64
+		// scenario is the name of the loaded scenario
+		scenario string
+
+		// metabolism is the upkeep of bots per cycle
+		metabolism Metabolism
 
-		bots []*Bot
+		space *cp.Space
+		// world is the topology, walls the static shapes of
+		// walled worlds
+		world World
+		walls []*cp.Shape
 
+		hulls Hulls
+		bots  []*Bot
+
+		// ctx stops the game before the next tick when
+		// cancelled
+		ctx context.Context
+		// numRunners is the number of workers running
+		// machines in parallel
 		numRunners int
-		wg         sync.WaitGroup
-		botChan    chan *Bot
+		pool       *WorkerPool
 
 		asteroids []*Asteroid
+		debris    []*Debris
+		docks     []*DockJoint
+		tethers   []*Tether
+		wells     []*GravityWell
+		// gravity is the velocity update function of
+		// objects pulled by wells
+		gravity cp.BodyVelocityFunc
+
+		listeners []EventFunc
 
-		// width and height of the game scene in pixels
-		w, h int
+		// history keeps snapshots for rewinding, nil if
+		// disabled
+		history *History
+
+		camera Camera
+	}
 
-		camera rl.Camera2D
+	// Camera is the view on the world. It is part of the
+	// game, so it is saved with the world.
+	Camera struct {
+		// Target is the world point shown at Offset on screen
+		Target cp.Vector
+		Offset cp.Vector
+		Zoom   float64
 	}
 )
 
 func (g *Game) init() {
 	g.paused = true
+	g.metabolism = defaultMetabolism
+	if g.cyclesPerTick <= 0 {
+		g.cyclesPerTick = 1
+	}
+	if g.substeps <= 0 {
+		g.substeps = defaultSubsteps
+	}
+	if g.speed <= 0 {
+		g.speed = 1
+	}
+	g.space = cp.NewSpace()
+	g.walls = nil
+	g.setWorld(World{})
+	g.initCollisions()
 	g.bots = make([]*Bot, 0, 128)
 	g.asteroids = make([]*Asteroid, 0, 64)
+	g.debris = nil
+	g.docks = nil
+	g.tethers = nil
+	g.wells = nil
+	g.gravity = nil
+	g.camera = Camera{Zoom: 1}
 
-	g.camera.Zoom = 1
-
-	g.numRunners = runtime.NumCPU() - 1
-	if g.numRunners < 2 {
-		g.numRunners = 2
+	if g.numRunners <= 0 {
+		g.numRunners = runtime.NumCPU() - 1
+		if g.numRunners < 2 {
+			g.numRunners = 2
+		}
 	}
 
-	physics.Init()
-
-	g.botChan = make(chan *Bot, 1)
-	for i := 0; i < g.numRunners; i++ {
-		go BotRunner(g, g.botChan)
+	if g.ctx == nil {
+		g.ctx = context.Background()
+	}
+	// the workers are kept when the world is restored
+	if g.pool == nil {
+		g.pool = NewWorkerPool(g.numRunners)
 	}
 }
 
-// Update is the main update loop
+// Close stops the workers of the game
+func (g *Game) Close() {
+	g.pool.Close()
+}
+
+// Update advances the game by the frame time dt.
+//
+// The game runs in ticks of fixed duration, independent of
+// the frame rate. Time left over is carried to the next frame.
 func (g *Game) Update(dt float32) {
-	if g.paused && !g.doStep {
+	if g.doStep {
+		g.doStep = false
+		g.Tick()
+		return
+	}
+	if g.paused {
 		return
 	}
-	g.doStep = false
+	g.accumulator += math.Min(float64(dt), maxFrameTime)
+	for g.accumulator >= timeStep {
+		g.accumulator -= timeStep
+		for i := 0; i < g.speed; i++ {
+			if err := g.Tick(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Paused returns true if the game is paused
+func (g *Game) Paused() bool {
+	return g.paused
+}
+
+// SetPaused pauses or resumes the game
+func (g *Game) SetPaused(paused bool) {
+	g.paused = paused
+}
+
+// SingleStep runs a single tick on the next update, also
+// when the game is paused
+func (g *Game) SingleStep() {
+	g.doStep = true
+}
+
+// Speed returns the fast-forward factor
+func (g *Game) Speed() int {
+	return g.speed
+}
+
+// SetSpeed fast-forwards the game by factor speed
+// (1..maxSpeed).
+//
+// A fast-forwarded frame runs speed times the machine
+// cycles and physics steps. They run as whole ticks, so
+// the world at a tick does not depend on the speed.
+func (g *Game) SetSpeed(speed int) {
+	if speed < 1 {
+		speed = 1
+	} else if speed > maxSpeed {
+		speed = maxSpeed
+	}
+	g.speed = speed
+}
 
-	// Run bot cycles
+// CurrentTick returns the number of ticks since the start
+func (g *Game) CurrentTick() int64 {
+	return g.tick
+}
+
+// Tick advances the game by one tick.
+//
+// All machine cycles of the tick run first, then the
+// physics steps. A tick always runs the same way no matter
+// how frames are timed.
+//
+// Returns an error if the game was cancelled. Cancellation
+// is checked before the tick starts, a tick that started is
+// always completed.
+func (g *Game) Tick() error {
+	if err := g.ctx.Err(); err != nil {
+		return err
+	}
 	for i := 0; i < g.cyclesPerTick; i++ {
-		g.wg.Add(len(g.bots))
-		for _, bot := range g.bots {
-			g.botChan <- bot
+		if err := g.cycle(); err != nil {
+			return err
 		}
-		g.wg.Wait()
-		g.step++
 	}
+	g.updateWells()
+	for i := 0; i < g.substeps; i++ {
+		g.space.Step(timeStep / float64(g.substeps))
+	}
+	g.wrap()
+	g.tick++
+	g.emit(Event{Type: EventTick})
+	if g.history != nil && g.tick%g.history.interval == 0 {
+		return g.snapshot()
+	}
+	return nil
+}
+
+// cycle runs the machines of all bots once.
+//
+// A cycle has three phases:
+//   - observe: the state of each bot is snapshot
+//   - think: the machines run in parallel, in any order.
+//     They only read their snapshot and record intents.
+//     Scans run here, on an index of the world built once
+//     per cycle.
+//   - act: the intents are applied to the world in the
+//     order of g.bots.
+//
+// The outcome does therefore not depend on the scheduling.
+func (g *Game) cycle() error {
+	scan := g.newScanIndex()
+	for _, b := range g.bots {
+		b.observe(scan)
+	}
+
+	if err := g.pool.Run(g.bots); err != nil {
+		return errors.Wrap(err, "running machines")
+	}
+
+	for _, b := range g.bots {
+		b.Execute()
+	}
+	g.commit()
+	g.reap()
+	g.step++
+	return nil
+}
+
+// commit applies all interactions between bots.
+//
+// Bots must not modify other objects while their machines
+// run in parallel. Interactions are collected and
+// applied in order after all machines ran.
+func (g *Game) commit() {
+	for _, b := range g.bots {
+		g.commitDock(b)
+		g.commitTether(b)
+		b.commitTransfer()
+		b.commitMine()
+		b.burn(g.metabolism.Cost(b))
+	}
+}
+
+// reap removes all bots that are no longer alive.
+//
+// What is left of their mass remains as debris. Debris
+// decays and is removed once it is too light, so it does
+// not pile up over long runs.
+func (g *Game) reap() {
+	debris := g.debris[:0]
+	for _, d := range g.debris {
+		d.decay()
+		if d.Mass() >= debrisMinMass {
+			debris = append(debris, d)
+			continue
+		}
+		d.remove()
+	}
+	for i := len(debris); i < len(g.debris); i++ {
+		g.debris[i] = nil
+	}
+	g.debris = debris
+
+	alive := g.bots[:0]
+	for _, b := range g.bots {
+		if b.Alive() {
+			alive = append(alive, b)
+			continue
+		}
+		pos, vel, mass := b.Position(), b.Velocity(), b.Mass()
+		g.undockAll(b)
+		g.release(b.tether)
+		b.remove()
+		if mass >= debrisMinMass {
+			g.debris = append(g.debris, NewDebris(g.space, mass, pos, vel))
+		}
+		g.emit(Event{Type: EventBotDeath, BotID: b.id, Position: pos})
+	}
+	// do not keep references to removed bots
+	for i := len(alive); i < len(g.bots); i++ {
+		g.bots[i] = nil
+	}
+	g.bots = alive
 }
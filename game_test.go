@@ -0,0 +1,179 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedTimestep(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	g.paused = false
+
+	d := NewDebris(g.space, 10, cp.Vector{}, cp.Vector{X: 60, Y: 0})
+	g.debris = append(g.debris, d)
+
+	// less than a tick is carried over
+	g.Update(timeStep / 2)
+	assert.Equal(t, int64(0), g.tick)
+	g.Update(timeStep / 2)
+	assert.Equal(t, int64(1), g.tick)
+
+	// a long frame runs several ticks
+	g.Update(3 * timeStep)
+	assert.Equal(t, int64(4), g.tick)
+	assert.Equal(t, int64(4*g.cyclesPerTick), g.step)
+	assert.InDelta(t, 60*4*timeStep, d.Position().X, 1e-9)
+
+	// paused games do not advance
+	g.paused = true
+	g.Update(timeStep)
+	assert.Equal(t, int64(4), g.tick)
+}
+
+func TestTicksIndependentOfFrameRate(t *testing.T) {
+	run := func(dt float32) (int64, cp.Vector) {
+		g := &Game{}
+		g.init()
+		defer g.Close()
+		g.paused = false
+		g.metabolism = Metabolism{}
+
+		b := NewBot(g.space, 1, testHull(t))
+		b.SetVelocity(10, 0)
+		b.SetAngularVelocity(1)
+		g.bots = append(g.bots, b)
+
+		for g.tick < 120 {
+			g.Update(dt)
+		}
+		return g.tick, b.Position()
+	}
+	ticks, pos := run(timeStep / 2)
+	otherTicks, otherPos := run(3 * timeStep)
+	assert.Equal(t, ticks, otherTicks)
+	assert.Equal(t, pos, otherPos)
+}
+
+func TestDeterminism(t *testing.T) {
+	hashes := func(scenario string, runners int) []uint64 {
+		g := &Game{seed: 42, numRunners: runners}
+		if !assert.NoError(t, g.Load(scenario)) {
+			return nil
+		}
+		defer g.Close()
+		var hs []uint64
+		for i := 0; i < 300; i++ {
+			g.Tick()
+			hs = append(hs, g.Hash())
+		}
+		return hs
+	}
+	for _, scenario := range []string{"all", "asteroid", "field"} {
+		t.Run(scenario, func(t *testing.T) {
+			expected := hashes(scenario, 1)
+			assert.Equal(t, expected, hashes(scenario, 1), "same runners")
+			for _, runners := range []int{2, 8} {
+				assert.Equal(t, expected, hashes(scenario, runners), "%d runners", runners)
+			}
+		})
+	}
+
+	// the seed changes the world
+	a, err := NewGame("field", 1)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer a.Close()
+	b, err := NewGame("field", 2)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer b.Close()
+	assert.NotEqual(t, a.Hash(), b.Hash())
+}
+
+// swarmProgram exercises all interactions of bots with
+// their surroundings
+const swarmProgram = `BEGIN EV
+	PSH CON 0
+	RID
+	PSH CON 0
+	IEQ
+END
+BEGIN EX
+	PSH CON 20
+	TRN
+	PSH CON 30
+	IMP
+END
+
+BEGIN EV
+	PSH CON 0
+	RID
+	PSH CON 0
+	GRT
+END
+BEGIN EX
+	PSH CON 5
+	GIV
+	DCK
+	RDX
+	NEG
+	RDY
+	NEG
+	THR
+END
+
+BEGIN EV
+	PSH CON 0
+	RID
+	PSH CON -1
+	IEQ
+END
+BEGIN EX
+	PSH CON 10
+	MNE
+	TTH
+	RDC
+	PSH CON 0
+	GRT
+	POP REG 0
+END
+`
+
+func TestSwarm(t *testing.T) {
+	g := &Game{seed: 1}
+	if !assert.NoError(t, g.Load("field")) {
+		return
+	}
+	defer g.Close()
+	program, err := NewParser(strings.NewReader(swarmProgram)).Parse()
+	if !assert.NoError(t, err) {
+		return
+	}
+	// bots on a grid between the asteroids
+	for x := -5; x < 5; x++ {
+		for y := -5; y < 5; y++ {
+			pos := cp.Vector{X: float64(x) * 40, Y: float64(y) * 40}
+			if !g.vacant(pos, 20) {
+				continue
+			}
+			b := NewBot(g.space, int16(len(g.bots)+1), g.hulls["standard"])
+			b.SetPosition(pos)
+			b.SetVelocity(float64(x%3)*20, float64(y%3)*20)
+			b.machine.program = program
+			g.bots = append(g.bots, b)
+		}
+	}
+	assert.Greater(t, len(g.bots), 50)
+
+	for i := 0; i < 100; i++ {
+		g.Tick()
+	}
+	assert.Equal(t, int64(100), g.step)
+}
@@ -5,7 +5,6 @@ go 1.24.0
 toolchain go1.24.2
 
 require (
-	github.com/gen2brain/raylib-go/physics v0.0.0-20250925154813-cab7881fba7a
 	github.com/gen2brain/raylib-go/raylib v0.55.1
 	github.com/go-kit/log v0.2.0
 	github.com/jakecoffman/cp v1.2.1
@@ -16,7 +15,6 @@ require (
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/ebitengine/purego v0.9.1 // indirect
-	github.com/gen2brain/raylib-go v4.5.0+incompatible // indirect
 	github.com/go-logfmt/logfmt v0.5.1 // indirect
 	github.com/kr/text v0.2.0 // indirect
 	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jakecoffman/cp"
+	"github.com/pkg/errors"
+)
+
+const (
+	// sunSprite is the texture of suns
+	sunSprite = "assets/planets (with filter)/sun - filter.png"
+	// planetSprites is the number of planet textures
+	planetSprites = 32
+
+	wellFrictionCoeff = 0.8
+)
+
+type (
+	// GravityWell is a planet or sun. It pulls bots,
+	// asteroids and debris with a force proportional to the
+	// inverse square of the distance to its center.
+	//
+	// Wells are kinematic: they are not moved by collisions
+	// or gravity. They either stay in place or follow a
+	// circular orbit.
+	GravityWell struct {
+		// Mu is the gravitational parameter G*M. The
+		// acceleration at distance r is Mu/r².
+		Mu     float64 `json:"mu"`
+		Radius float64 `json:"radius"`
+		// Position of wells without orbit
+		Position cp.Vector `json:"position"`
+		Orbit    Orbit     `json:"orbit"`
+		// Sprite is the texture file the well is drawn with
+		Sprite string `json:"sprite"`
+
+		body  *cp.Body
+		shape *cp.Shape
+	}
+
+	// Orbit is a circular, counter-clockwise orbit
+	Orbit struct {
+		Center cp.Vector `json:"center"`
+		// Radius is zero for wells without orbit
+		Radius float64 `json:"radius"`
+		// Period is the time of one revolution in seconds
+		Period float64 `json:"period"`
+		// Phase is the angle at tick 0
+		Phase float64 `json:"phase"`
+	}
+)
+
+// PlanetSprite returns the n-th (1..32) planet texture, n
+// outside wraps around
+func PlanetSprite(n int) string {
+	i := ((n-1)%planetSprites+planetSprites)%planetSprites + 1
+	return fmt.Sprintf("assets/planets (with filter)/planet (%d) filter.png", i)
+}
+
+// OrbitalPeriod returns the period of a circular orbit of
+// radius r around a body with gravitational parameter mu
+func OrbitalPeriod(mu, r float64) float64 {
+	return 2 * math.Pi * math.Sqrt(r*r*r/mu)
+}
+
+// AddWell adds the gravity well w to the game
+func (g *Game) AddWell(w GravityWell) *GravityWell {
+	well := &w
+	well.body = g.space.AddBody(cp.NewKinematicBody())
+	well.body.UserData = well
+	well.shape = cp.NewCircle(well.body, well.Radius, cp.Vector{})
+	well.shape.SetFriction(wellFrictionCoeff)
+	well.shape.UserData = well
+	well.shape.Filter.Categories = SHAPE_CATEGORY_WELL
+	well.shape.SetCollisionType(COLLISION_TYPE_WELL)
+	g.space.AddShape(well.shape)
+	well.move(float64(g.tick) * timeStep)
+	g.wells = append(g.wells, well)
+	return well
+}
+
+// validate returns an error if the radius of w is not
+// positive or its gravitational parameter is negative
+func (w GravityWell) validate() error {
+	if !(w.Radius > 0) || math.IsInf(w.Radius, 1) {
+		return errors.Errorf("invalid well radius %v", w.Radius)
+	}
+	if !(w.Mu >= 0) || math.IsInf(w.Mu, 1) {
+		return errors.Errorf("invalid well mu %v", w.Mu)
+	}
+	return nil
+}
+
+// move places the well on its orbit at time t and sets
+// its velocity along the orbit
+func (w *GravityWell) move(t float64) {
+	if w.Orbit.Radius <= 0 || w.Orbit.Period <= 0 {
+		w.body.SetPosition(w.Position)
+		return
+	}
+	omega := 2 * math.Pi / w.Orbit.Period
+	dir := cp.ForAngle(w.Orbit.Phase + omega*t)
+	w.body.SetPosition(w.Orbit.Center.Add(dir.Mult(w.Orbit.Radius)))
+	w.body.SetVelocityVector(dir.Perp().Mult(omega * w.Orbit.Radius))
+}
+
+// acceleration returns the acceleration the well causes at
+// p. Inside the well, it decreases linearly towards the
+// center.
+func (w *GravityWell) acceleration(p cp.Vector) cp.Vector {
+	d := w.body.Position().Sub(p)
+	r := math.Max(d.Length(), w.Radius)
+	if r == 0 {
+		return cp.Vector{}
+	}
+	return d.Mult(w.Mu / (r * r * r))
+}
+
+// updateWells moves the wells to their position at the
+// current tick and lets them pull all objects.
+//
+// The velocity update function is set on every tick, so
+// objects created since the last tick are pulled too.
+func (g *Game) updateWells() {
+	if len(g.wells) == 0 {
+		return
+	}
+	t := float64(g.tick) * timeStep
+	for _, w := range g.wells {
+		w.move(t)
+	}
+	if g.gravity == nil {
+		g.gravity = func(body *cp.Body, gravity cp.Vector, damping, dt float64) {
+			p := body.Position()
+			for _, w := range g.wells {
+				gravity = gravity.Add(w.acceleration(p))
+			}
+			cp.BodyUpdateVelocity(body, gravity, damping, dt)
+		}
+	}
+	for _, b := range g.bots {
+		b.SetVelocityUpdateFunc(g.gravity)
+	}
+	for _, a := range g.asteroids {
+		a.SetVelocityUpdateFunc(g.gravity)
+	}
+	for _, d := range g.debris {
+		d.SetVelocityUpdateFunc(g.gravity)
+	}
+}
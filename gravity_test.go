@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWellAcceleration(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	w := g.AddWell(GravityWell{Mu: 1000, Radius: 5})
+
+	assert.InDelta(t, -10, w.acceleration(cp.Vector{X: 10}).X, 1e-9)
+	assert.InDelta(t, -2.5, w.acceleration(cp.Vector{X: 20}).X, 1e-9)
+	assert.InDelta(t, 2.5, w.acceleration(cp.Vector{Y: -20}).Y, 1e-9)
+	// inside the well the pull decreases towards the center
+	assert.InDelta(t, -20, w.acceleration(cp.Vector{X: 2.5}).X, 1e-9)
+	assert.Equal(t, cp.Vector{}, w.acceleration(cp.Vector{}))
+}
+
+func TestCircularOrbit(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	const mu, r = 1e7, 1000.
+	g.AddWell(GravityWell{Mu: mu, Radius: 100})
+
+	d := NewDebris(g.space, 10, cp.Vector{X: r}, cp.Vector{Y: math.Sqrt(mu / r)})
+	g.debris = append(g.debris, d)
+
+	// a quarter of an orbit
+	ticks := int(OrbitalPeriod(mu, r) / 4 / timeStep)
+	for i := 0; i < ticks; i++ {
+		g.Tick()
+	}
+	assert.InDelta(t, r, d.Position().Length(), r*.01)
+	assert.InDelta(t, math.Pi/2, d.Position().ToAngle(), .05)
+}
+
+func TestOrbitingWell(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	w := g.AddWell(GravityWell{
+		Mu:     1,
+		Radius: 10,
+		Orbit:  Orbit{Center: cp.Vector{X: 100}, Radius: 50, Period: 4, Phase: math.Pi / 2},
+	})
+	assert.InDelta(t, 100, w.body.Position().X, 1e-9)
+	assert.InDelta(t, 50, w.body.Position().Y, 1e-9)
+
+	// half an orbit
+	for i := 0; i < int(2/timeStep); i++ {
+		g.Tick()
+	}
+	g.updateWells()
+	assert.InDelta(t, 100, w.body.Position().X, 1e-6)
+	assert.InDelta(t, -50, w.body.Position().Y, 1e-6)
+	assert.InDelta(t, 2*math.Pi*50/4, w.body.Velocity().X, 1e-6)
+}
+
+func TestWellSprites(t *testing.T) {
+	assert.Equal(t, PlanetSprite(1), PlanetSprite(planetSprites+1))
+	assert.Equal(t, PlanetSprite(planetSprites), PlanetSprite(0))
+	assert.Equal(t, PlanetSprite(planetSprites-1), PlanetSprite(-1))
+	for _, sprite := range []string{sunSprite, PlanetSprite(1), PlanetSprite(planetSprites)} {
+		_, err := os.Stat(sprite)
+		assert.NoError(t, err, sprite)
+	}
+}
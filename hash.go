@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+
+	"github.com/jakecoffman/cp"
+)
+
+type (
+	// stateHash accumulates the world state into a hash
+	stateHash struct {
+		hash.Hash64
+		buf [8]byte
+	}
+)
+
+// Hash returns a hash of the world state: the positions and
+// velocities of all objects and the registers of all bots.
+//
+// Two games that ran from the same seed with the same
+// inputs have the same hash after each tick.
+func (g *Game) Hash() uint64 {
+	h := &stateHash{Hash64: fnv.New64a()}
+	h.int(g.tick)
+	h.int(g.step)
+	for _, b := range g.bots {
+		h.int(int64(b.id))
+		h.body(b.Body)
+		for _, r := range b.machine.registers {
+			h.int(int64(r))
+		}
+	}
+	for _, a := range g.asteroids {
+		h.body(a.Body)
+	}
+	for _, d := range g.debris {
+		h.body(d.Body)
+	}
+	return h.Sum64()
+}
+
+func (h *stateHash) int(i int64) {
+	binary.LittleEndian.PutUint64(h.buf[:], uint64(i))
+	h.Write(h.buf[:])
+}
+
+func (h *stateHash) float(f float64) {
+	binary.LittleEndian.PutUint64(h.buf[:], math.Float64bits(f))
+	h.Write(h.buf[:])
+}
+
+func (h *stateHash) vector(v cp.Vector) {
+	h.float(v.X)
+	h.float(v.Y)
+}
+
+func (h *stateHash) body(b *cp.Body) {
+	h.vector(b.Position())
+	h.vector(b.Velocity())
+	h.float(b.Angle())
+	h.float(b.AngularVelocity())
+	h.float(b.Mass())
+}
@@ -0,0 +1,16 @@
+//go:build headless
+
+package main
+
+// Built with the headless tag, moonshot has no window and
+// does not depend on raylib. Only the sim command is
+// available.
+
+func runWindow(args []string) int {
+	errLog.Log("msg", "built without window, use moonshot sim")
+	return 2
+}
+
+func runReplay(args []string) int {
+	return runWindow(args)
+}
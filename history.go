@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultHistoryInterval is the default number of ticks
+	// between snapshots
+	defaultHistoryInterval = 60
+	// defaultHistorySize is the default number of snapshots
+	// kept
+	defaultHistorySize = 30
+)
+
+type (
+	// History is a ring buffer of snapshots of the world,
+	// taken every interval ticks.
+	//
+	// The game can be rewound to any tick since the oldest
+	// snapshot. The closest snapshot before that tick is
+	// restored and the game is simulated up to the tick.
+	History struct {
+		interval int64
+		// snapshots is the ring, first is the index of the
+		// oldest snapshot
+		snapshots []snapshot
+		first, n  int
+	}
+
+	snapshot struct {
+		tick int64
+		save []byte
+	}
+)
+
+// NewHistory creates a history keeping size snapshots, one
+// every interval ticks
+func NewHistory(interval, size int) *History {
+	if interval <= 0 {
+		interval = defaultHistoryInterval
+	}
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+	return &History{
+		interval:  int64(interval),
+		snapshots: make([]snapshot, size),
+	}
+}
+
+// Oldest returns the tick of the oldest snapshot, the
+// earliest tick the game can be rewound to
+func (h *History) Oldest() (int64, bool) {
+	if h.n == 0 {
+		return 0, false
+	}
+	return h.snapshots[h.first].tick, true
+}
+
+// add adds a snapshot, replacing the oldest if the history
+// is full
+func (h *History) add(s snapshot) {
+	i := (h.first + h.n) % len(h.snapshots)
+	h.snapshots[i] = s
+	if h.n < len(h.snapshots) {
+		h.n++
+		return
+	}
+	h.first = (h.first + 1) % len(h.snapshots)
+}
+
+// at returns the i-th oldest snapshot
+func (h *History) at(i int) snapshot {
+	return h.snapshots[(h.first+i)%len(h.snapshots)]
+}
+
+// find returns the latest snapshot at or before tick
+func (h *History) find(tick int64) (snapshot, bool) {
+	for i := h.n - 1; i >= 0; i-- {
+		if s := h.at(i); s.tick <= tick {
+			return s, true
+		}
+	}
+	return snapshot{}, false
+}
+
+// truncate drops all snapshots after tick
+func (h *History) truncate(tick int64) {
+	for h.n > 0 && h.at(h.n-1).tick > tick {
+		h.snapshots[(h.first+h.n-1)%len(h.snapshots)] = snapshot{}
+		h.n--
+	}
+}
+
+// EnableHistory starts keeping size snapshots of g, one
+// every interval ticks. The current state is the first
+// snapshot.
+func (g *Game) EnableHistory(interval, size int) error {
+	g.history = NewHistory(interval, size)
+	return g.snapshot()
+}
+
+// snapshot adds the current state to the history. The game
+// is resynced first, so it continues like its snapshot.
+func (g *Game) snapshot() error {
+	g.Resync()
+	var buf bytes.Buffer
+	if err := g.Save(&buf); err != nil {
+		return errors.Wrap(err, "error taking snapshot")
+	}
+	g.history.add(snapshot{tick: g.tick, save: buf.Bytes()})
+	return nil
+}
+
+// Rewind sets the game back to tick.
+//
+// The closest earlier snapshot is restored and simulated
+// up to tick. Rewinding to the same tick always gives the
+// same world. Listeners are not notified of events while
+// restoring and simulating, they get an EventRewind once
+// the game is at tick. The camera, speed and pause state
+// are kept.
+func (g *Game) Rewind(tick int64) error {
+	if g.history == nil {
+		return errors.New("history is not enabled")
+	}
+	if tick > g.tick {
+		return errors.Errorf("tick %d is in the future", tick)
+	}
+	s, ok := g.history.find(tick)
+	if !ok {
+		oldest, _ := g.history.Oldest()
+		return errors.Errorf("tick %d is before the oldest snapshot at %d", tick, oldest)
+	}
+
+	listeners := g.listeners
+	g.listeners = nil
+	defer func() {
+		g.listeners = listeners
+	}()
+
+	camera, paused := g.camera, g.paused
+	if err := g.Restore(bytes.NewReader(s.save)); err != nil {
+		return errors.Wrap(err, "error restoring snapshot")
+	}
+	g.camera, g.paused = camera, paused
+	g.history.truncate(tick)
+
+	for g.tick < tick {
+		if err := g.Tick(); err != nil {
+			return err
+		}
+	}
+	g.listeners = listeners
+	g.emit(Event{Type: EventRewind})
+	return nil
+}
+
+// RewindBy sets the game back by ticks. It rewinds as far
+// as the history reaches.
+func (g *Game) RewindBy(ticks int64) error {
+	if g.history == nil {
+		return errors.New("history is not enabled")
+	}
+	tick := g.tick - ticks
+	if oldest, ok := g.history.Oldest(); ok && tick < oldest {
+		tick = oldest
+	}
+	return g.Rewind(tick)
+}
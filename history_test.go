@@ -0,0 +1,165 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryRing(t *testing.T) {
+	h := NewHistory(10, 3)
+	_, ok := h.Oldest()
+	assert.False(t, ok)
+
+	for tick := int64(0); tick <= 40; tick += 10 {
+		h.add(snapshot{tick: tick})
+	}
+	oldest, ok := h.Oldest()
+	assert.True(t, ok)
+	assert.Equal(t, int64(20), oldest)
+
+	s, ok := h.find(35)
+	assert.True(t, ok)
+	assert.Equal(t, int64(30), s.tick)
+	_, ok = h.find(19)
+	assert.False(t, ok)
+
+	h.truncate(25)
+	s, ok = h.find(100)
+	assert.True(t, ok)
+	assert.Equal(t, int64(20), s.tick)
+
+	h.add(snapshot{tick: 30})
+	s, _ = h.find(100)
+	assert.Equal(t, int64(30), s.tick)
+}
+
+func TestRewind(t *testing.T) {
+	g, err := NewGame("field", 42)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer g.Close()
+	if !assert.NoError(t, g.EnableHistory(10, 5)) {
+		return
+	}
+	var events, rewinds int
+	g.Subscribe(func(e Event) {
+		if e.Type == EventRewind {
+			rewinds++
+			return
+		}
+		events++
+	})
+
+	hashes := make(map[int64]uint64)
+	for i := 0; i < 65; i++ {
+		g.Tick()
+		hashes[g.tick] = g.Hash()
+	}
+
+	// snapshots at 20, 30 .. 60 are left
+	assert.Error(t, g.Rewind(19))
+	assert.Error(t, g.Rewind(66))
+
+	g.camera.Zoom = 2
+	before := events
+	if !assert.NoError(t, g.Rewind(23)) {
+		return
+	}
+	assert.Equal(t, before, events, "listeners are muted while simulating")
+	assert.Equal(t, 1, rewinds)
+	assert.Equal(t, int64(23), g.tick)
+	assert.Equal(t, hashes[23], g.Hash())
+	assert.Equal(t, 2., g.camera.Zoom)
+
+	for g.tick < 65 {
+		g.Tick()
+		if !assert.Equal(t, hashes[g.tick], g.Hash(), "tick %d", g.tick) {
+			return
+		}
+	}
+
+	// snapshots after the rewind are taken again
+	if !assert.NoError(t, g.RewindBy(1000)) {
+		return
+	}
+	assert.Equal(t, int64(20), g.tick)
+	assert.Equal(t, hashes[20], g.Hash())
+}
+
+func TestRewindContinuesRun(t *testing.T) {
+	for _, scenario := range []string{"field", "belt"} {
+		t.Run(scenario, func(t *testing.T) {
+			g, err := NewGame(scenario, 42)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer g.Close()
+			if !assert.NoError(t, g.EnableHistory(60, 30)) {
+				return
+			}
+
+			hashes := make(map[int64]uint64)
+			var contacts int
+			for i := 0; i < 1200; i++ {
+				g.Tick()
+				hashes[g.tick] = g.Hash()
+				contacts += testContacts(g)
+			}
+			// the run had objects touching
+			if !assert.Greater(t, contacts, 0) {
+				return
+			}
+
+			for tick := int64(1190); tick >= 700; tick -= 49 {
+				if !assert.NoError(t, g.Rewind(tick)) {
+					return
+				}
+				if !assert.Equal(t, hashes[tick], g.Hash(), "tick %d", tick) {
+					return
+				}
+			}
+
+			// the rewound game goes on like the recorded run
+			for g.tick < 1200 {
+				g.Tick()
+				if !assert.Equal(t, hashes[g.tick], g.Hash(), "tick %d", g.tick) {
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestRewindWithoutHistory(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	assert.Error(t, g.Rewind(0))
+	assert.Error(t, g.RewindBy(1))
+}
+
+func TestTimeControls(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+
+	assert.True(t, g.Paused())
+	g.SingleStep()
+	g.Update(timeStep)
+	assert.Equal(t, int64(1), g.CurrentTick())
+	g.Update(timeStep)
+	assert.Equal(t, int64(1), g.CurrentTick())
+
+	g.SetPaused(false)
+	g.SetSpeed(4)
+	g.Update(timeStep)
+	assert.Equal(t, int64(5), g.CurrentTick())
+	assert.Equal(t, int64(5*g.cyclesPerTick), g.step)
+
+	g.SetSpeed(0)
+	assert.Equal(t, 1, g.Speed())
+	g.SetSpeed(1000)
+	assert.Equal(t, maxSpeed, g.Speed())
+}
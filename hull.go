@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// hullsFile is the default location of the hull definitions
+const hullsFile = "gamedata/hulls.json"
+
+type (
+	// Hull is the blueprint of a bot type.
+	//
+	// Hulls are loaded from data files, so bot types can be
+	// added and balanced without touching the code.
+	Hull struct {
+		Name string `json:"-"`
+
+		Mass   float64 `json:"mass"`
+		Radius float64 `json:"radius"`
+		// Memory is the number of registers of the bot's machine
+		Memory int `json:"memory"`
+
+		Reactor   ReactorComponent  `json:"reactor"`
+		Thrusters ThrusterComponent `json:"thrusters"`
+		Scanner   ScannerComponent  `json:"scanner"`
+		Miner     MinerComponent    `json:"miner"`
+		Tether    TetherComponent   `json:"tether"`
+	}
+
+	// ReactorComponent is the Leonhard reactor converting
+	// mass to energy and back
+	ReactorComponent struct {
+		Efficiency float64 `json:"efficiency"`
+	}
+
+	ThrusterComponent struct {
+		// Curve maps thrust steps to forces. It must be
+		// sorted by step.
+		Curve []ThrustStep `json:"curve"`
+		// TurnImpulse is the maximum angular impulse the
+		// attitude thrusters can apply per cycle
+		TurnImpulse float64 `json:"turn_impulse"`
+	}
+
+	// ThrustStep is a point of the thruster curve. Commanded
+	// thrust of at least Step will generate Force.
+	ThrustStep struct {
+		Step  int16   `json:"step"`
+		Force float64 `json:"force"`
+	}
+
+	ScannerComponent struct {
+		// FOV in degrees
+		FOV   float64 `json:"fov"`
+		Range float64 `json:"range"`
+	}
+
+	MinerComponent struct {
+		Strength float64 `json:"strength"`
+	}
+
+	TetherComponent struct {
+		// Range is the maximum distance to attach a tether
+		Range float64 `json:"range"`
+		// BreakingForce is the maximum tension of the tether
+		BreakingForce float64 `json:"breaking_force"`
+	}
+
+	Hulls map[string]*Hull
+)
+
+// LoadHulls reads the hull definitions, keyed by name,
+// from r.
+func LoadHulls(r io.Reader) (Hulls, error) {
+	hulls := make(Hulls)
+	if err := json.NewDecoder(r).Decode(&hulls); err != nil {
+		return nil, errors.Wrap(err, "error decoding hulls")
+	}
+	if err := hulls.init(); err != nil {
+		return nil, err
+	}
+	return hulls, nil
+}
+
+// init names the decoded hulls after their keys and
+// validates them
+func (hs Hulls) init() error {
+	for name, h := range hs {
+		if h == nil {
+			return errors.Errorf("invalid hull %s: hull is null", name)
+		}
+		h.Name = name
+		if err := h.validate(); err != nil {
+			return errors.Wrapf(err, "invalid hull %s", name)
+		}
+	}
+	return nil
+}
+
+func LoadHullsFile(path string) (Hulls, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening hulls file")
+	}
+	defer f.Close()
+	return LoadHulls(f)
+}
+
+func (h *Hull) validate() error {
+	if h.Mass <= botMinMass {
+		return fmt.Errorf("mass must be > %v", botMinMass)
+	}
+	if h.Radius <= 0 {
+		return fmt.Errorf("radius must be > 0")
+	}
+	if h.Memory <= 0 {
+		return fmt.Errorf("memory must be > 0")
+	}
+	if h.Reactor.Efficiency <= 0 || h.Reactor.Efficiency > 1 {
+		return fmt.Errorf("reactor efficiency must be in (0, 1]")
+	}
+	if len(h.Thrusters.Curve) == 0 {
+		return fmt.Errorf("thruster curve must not be empty")
+	}
+	for i := 1; i < len(h.Thrusters.Curve); i++ {
+		if h.Thrusters.Curve[i].Step <= h.Thrusters.Curve[i-1].Step {
+			return fmt.Errorf("thruster curve must be sorted by step")
+		}
+	}
+	if h.Thrusters.TurnImpulse < 0 {
+		return fmt.Errorf("turn impulse must be >= 0")
+	}
+	if h.Scanner.FOV < 0 || h.Scanner.FOV > 360 {
+		return fmt.Errorf("scanner fov must be in [0, 360]")
+	}
+	if h.Scanner.Range < 0 {
+		return fmt.Errorf("scanner range must be >= 0")
+	}
+	if h.Miner.Strength < 0 {
+		return fmt.Errorf("miner strength must be >= 0")
+	}
+	if h.Tether.Range < 0 {
+		return fmt.Errorf("tether range must be >= 0")
+	}
+	// a tether that can not hold would snap at once
+	if h.Tether.Range > 0 && h.Tether.BreakingForce <= 0 {
+		return fmt.Errorf("tether breaking force must be > 0")
+	}
+	return nil
+}
+
+// Force translates a thrust step to a force following
+// the thruster curve
+func (t ThrusterComponent) Force(step int16) float64 {
+	force := t.Curve[0].Force
+	for _, s := range t.Curve {
+		if step < s.Step {
+			break
+		}
+		force = s.Force
+	}
+	return force
+}
+
+// Components returns the number of installed components.
+func (h *Hull) Components() int {
+	// every hull has a reactor and thrusters
+	n := 2
+	if h.Scanner.Range > 0 {
+		n++
+	}
+	if h.Miner.Strength > 0 {
+		n++
+	}
+	if h.Tether.Range > 0 {
+		n++
+	}
+	return n
+}
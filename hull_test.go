@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testHull(t testing.TB) *Hull {
+	t.Helper()
+	hulls, err := LoadHullsFile(hullsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hulls["standard"]
+}
+
+func TestLoadHulls(t *testing.T) {
+	hulls, err := LoadHullsFile(hullsFile)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	h, ok := hulls["standard"]
+	if !assert.True(t, ok, "standard hull") {
+		return
+	}
+	assert.Equal(t, "standard", h.Name)
+	assert.Equal(t, 16, h.Memory)
+	assert.Equal(t, 80.0, h.Thrusters.Force(-20))
+	assert.Equal(t, 80.0, h.Thrusters.Force(99))
+	assert.Equal(t, 140.0, h.Thrusters.Force(100))
+	assert.Equal(t, 200.0, h.Thrusters.Force(500))
+	assert.Equal(t, 5, h.Components())
+}
+
+func TestLoadHullsValidates(t *testing.T) {
+	data := `{
+	"broken": {
+		"mass": 100,
+		"radius": 8,
+		"memory": 16,
+		"reactor": { "efficiency": 0.65 },
+		"thrusters": {
+			"curve": [
+				{ "step": 100, "force": 140 },
+				{ "step": 0, "force": 80 }
+			]
+		}
+	}
+}`
+	_, err := LoadHulls(strings.NewReader(data))
+	if err == nil {
+		t.Error("expect error on unsorted thruster curve")
+		return
+	}
+}
+
+func TestLoadNullHull(t *testing.T) {
+	_, err := LoadHulls(strings.NewReader(`{"x": null}`))
+	assert.Error(t, err)
+}
+
+func TestHullValidatesComponents(t *testing.T) {
+	for name, broken := range map[string]func(h *Hull){
+		"turn impulse":  func(h *Hull) { h.Thrusters.TurnImpulse = -1 },
+		"scanner fov":   func(h *Hull) { h.Scanner.FOV = -10 },
+		"scanner range": func(h *Hull) { h.Scanner.Range = -1 },
+		"miner":         func(h *Hull) { h.Miner.Strength = -1 },
+		"tether range":  func(h *Hull) { h.Tether.Range = -1 },
+		"tether force":  func(h *Hull) { h.Tether.BreakingForce = 0 },
+	} {
+		h := *testHull(t)
+		if !assert.NoError(t, h.validate()) {
+			return
+		}
+		broken(&h)
+		assert.Error(t, h.validate(), name)
+	}
+}
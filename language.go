@@ -24,6 +24,8 @@ const (
 	RDX Token = 32 // Read X vector and push it on the stack
 	RDY Token = 33 // Read Y vector and push it on the stack
 	RDE Token = 34 // Read total energy and push it on the stack
+	RDA Token = 35 // Read heading in degrees and push it on the stack
+	RDC Token = 36 // Read impact energy of collisions since last cycle and push it on the stack
 
 	PSH Token = 64 // Push
 	POP Token = 65 // Pop
@@ -54,10 +56,16 @@ const (
 	RID Token = 1024 // Pushes the ID of the first object in current fov
 	SCN Token = 1025 // Pop x, y and pushes x, y to first object in current fov
 	THR Token = 1026 // Pop x, y and thrust for the vector
-	TRN Token = 1027 // Pop x, y and turn by the angle given by unit vector with atan(y, x)
+	TRN Token = 1027 // Pop x and change the angular velocity by x degrees per second
 	MNE Token = 1028 // Pop and mine with strength x
 	REP Token = 1029 // Pop and reproduce using x energy
 	IMP Token = 1030 // Pop x and thrust for strength x for current heading
+	GIV Token = 1031 // Pop x and give x energy to the touching remote object
+	TAK Token = 1032 // Pop x and take x energy from the touching remote object
+	DCK Token = 1033 // Dock to the touching remote bot
+	UND Token = 1034 // Undock from the remote bot
+	TTH Token = 1035 // Attach a tether to the remote asteroid
+	RLS Token = 1036 // Release the tether
 )
 
 type (
@@ -89,6 +97,10 @@ func Translate(token Token) Instruction {
 		return &ReadY{}
 	case RDE:
 		return &ReadEnergy{}
+	case RDA:
+		return &ReadHeading{}
+	case RDC:
+		return &ReadImpact{}
 
 	case PSH:
 		return &Push{}
@@ -141,6 +153,18 @@ func Translate(token Token) Instruction {
 		return &Reproduce{}
 	case IMP:
 		return &Impulse{}
+	case GIV:
+		return &Give{}
+	case TAK:
+		return &Take{}
+	case DCK:
+		return &Dock{}
+	case UND:
+		return &Undock{}
+	case TTH:
+		return &AttachTether{}
+	case RLS:
+		return &ReleaseTether{}
 
 	case ILLEGAL:
 		fallthrough
@@ -178,6 +202,10 @@ func (t Token) String() string {
 		return "RDY"
 	case RDE:
 		return "RDE"
+	case RDA:
+		return "RDA"
+	case RDC:
+		return "RDC"
 
 	case PSH:
 		return "PSH"
@@ -237,6 +265,18 @@ func (t Token) String() string {
 		return "REP"
 	case IMP:
 		return "IMP"
+	case GIV:
+		return "GIV"
+	case TAK:
+		return "TAK"
+	case DCK:
+		return "DCK"
+	case UND:
+		return "UND"
+	case TTH:
+		return "TTH"
+	case RLS:
+		return "RLS"
 
 	case ILLEGAL:
 		fallthrough
@@ -392,6 +432,36 @@ func (e *ReadEnergy) Parse(p *Parser, program *AST) error {
 	return nil
 }
 
+type ReadHeading struct{}
+
+func (r ReadHeading) String() string {
+	return RDA.String()
+}
+func (r ReadHeading) Run(m *Machine, code AST) {
+	m.run(m, code, func() {
+		m.stack.Push(m.state.Heading())
+	})
+}
+func (r *ReadHeading) Parse(p *Parser, program *AST) error {
+	*program = append(*program, r)
+	return nil
+}
+
+type ReadImpact struct{}
+
+func (r ReadImpact) String() string {
+	return RDC.String()
+}
+func (r ReadImpact) Run(m *Machine, code AST) {
+	m.run(m, code, func() {
+		m.stack.Push(m.state.Impact())
+	})
+}
+func (r *ReadImpact) Parse(p *Parser, program *AST) error {
+	*program = append(*program, r)
+	return nil
+}
+
 type Push struct {
 	Source Token
 	Value  int16
@@ -443,6 +513,12 @@ func (e Pop) String() string {
 }
 func (e Pop) Run(m *Machine, code AST) {
 	m.run(m, code, func() {
+		if len(*m.stack) <= 0 {
+			return
+		}
+		if int(e.Index) > len(m.registers)-1 || e.Index < 0 {
+			return
+		}
 		m.registers[e.Index] = m.stack.Pop()
 	})
 }
@@ -917,3 +993,101 @@ func (e *Reproduce) Parse(p *Parser, program *AST) error {
 	*program = append(*program, e)
 	return nil
 }
+
+type Give struct{}
+
+func (e Give) String() string {
+	return GIV.String()
+}
+func (e Give) Run(m *Machine, code AST) {
+	m.run(m, code, func() {
+		if len(*m.stack) <= 0 {
+			return
+		}
+		a := m.stack.Pop()
+		m.state.Give(a)
+	})
+}
+func (e *Give) Parse(p *Parser, program *AST) error {
+	*program = append(*program, e)
+	return nil
+}
+
+type Take struct{}
+
+func (e Take) String() string {
+	return TAK.String()
+}
+func (e Take) Run(m *Machine, code AST) {
+	m.run(m, code, func() {
+		if len(*m.stack) <= 0 {
+			return
+		}
+		a := m.stack.Pop()
+		m.state.Take(a)
+	})
+}
+func (e *Take) Parse(p *Parser, program *AST) error {
+	*program = append(*program, e)
+	return nil
+}
+
+type Dock struct{}
+
+func (e Dock) String() string {
+	return DCK.String()
+}
+func (e Dock) Run(m *Machine, code AST) {
+	m.run(m, code, func() {
+		m.state.Dock()
+	})
+}
+func (e *Dock) Parse(p *Parser, program *AST) error {
+	*program = append(*program, e)
+	return nil
+}
+
+type Undock struct{}
+
+func (e Undock) String() string {
+	return UND.String()
+}
+func (e Undock) Run(m *Machine, code AST) {
+	m.run(m, code, func() {
+		m.state.Undock()
+	})
+}
+func (e *Undock) Parse(p *Parser, program *AST) error {
+	*program = append(*program, e)
+	return nil
+}
+
+type AttachTether struct{}
+
+func (e AttachTether) String() string {
+	return TTH.String()
+}
+func (e AttachTether) Run(m *Machine, code AST) {
+	m.run(m, code, func() {
+		m.state.AttachTether()
+	})
+}
+func (e *AttachTether) Parse(p *Parser, program *AST) error {
+	*program = append(*program, e)
+	return nil
+}
+
+type ReleaseTether struct{}
+
+func (e ReleaseTether) String() string {
+	return RLS.String()
+}
+func (e ReleaseTether) Run(m *Machine, code AST) {
+	m.run(m, code, func() {
+		m.state.ReleaseTether()
+	})
+}
+func (e *ReleaseTether) Parse(p *Parser, program *AST) error {
+	*program = append(*program, e)
+	return nil
+}
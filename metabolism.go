@@ -0,0 +1,31 @@
+package main
+
+type (
+	// Metabolism is the upkeep bots pay each cycle.
+	//
+	// Costs are in energy, the mass burnt depends on the
+	// bot's Leonhard efficiency.
+	Metabolism struct {
+		// Base cost of every bot
+		Base float64
+		// Instruction is the cost per instruction of the program
+		Instruction float64
+		// Component is the cost per installed component
+		Component float64
+	}
+)
+
+// defaultMetabolism is used unless a scenario configures
+// its own
+var defaultMetabolism = Metabolism{
+	Base:        0.001,
+	Instruction: 0.0001,
+	Component:   0.0005,
+}
+
+// Cost returns the upkeep of b per cycle
+func (m Metabolism) Cost(b *Bot) float64 {
+	return m.Base +
+		m.Instruction*float64(b.machine.program.Size()) +
+		m.Component*float64(b.hull.Components())
+}
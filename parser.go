@@ -138,6 +138,10 @@ func (s *Scanner) scanIdent() (tok Token, lit string) {
 		return RDY, buf.String()
 	case "RDE":
 		return RDE, buf.String()
+	case "RDA":
+		return RDA, buf.String()
+	case "RDC":
+		return RDC, buf.String()
 
 	case "PSH":
 		return PSH, buf.String()
@@ -197,6 +201,18 @@ func (s *Scanner) scanIdent() (tok Token, lit string) {
 		return REP, buf.String()
 	case "IMP":
 		return IMP, buf.String()
+	case "GIV":
+		return GIV, buf.String()
+	case "TAK":
+		return TAK, buf.String()
+	case "DCK":
+		return DCK, buf.String()
+	case "UND":
+		return UND, buf.String()
+	case "TTH":
+		return TTH, buf.String()
+	case "RLS":
+		return RLS, buf.String()
 
 	default:
 		return LITERAL, buf.String()
@@ -45,7 +45,7 @@ END
 		return
 	}
 
-	m := NewMachine()
+	m := NewMachine(16)
 	m.run = runInstructionDebug
 	m.program = program
 	stateMock := &StateMock{}
@@ -84,6 +84,10 @@ func TestFullLanguage(t *testing.T) {
 	RDE
 	PSH CON 0
 	GRT
+	RDA
+	AND
+	RDC
+	IOR
 	PSH CON 2
 	POP REG 0
 	PSH CON 3
@@ -136,6 +140,14 @@ BEGIN EX
 	DIV
 	REP
 	IMP
+	PSH CON 7
+	GIV
+	PSH CON 8
+	TAK
+	DCK
+	UND
+	TTH
+	RLS
 END
 `
 	p := NewParser(strings.NewReader(code))
@@ -145,7 +157,7 @@ END
 		return
 	}
 
-	m := NewMachine()
+	m := NewMachine(16)
 	m.run = runWithBreak(44, func(m *Machine) bool {
 		if !assert.Equal(t, 44, m.pc) {
 			return false
@@ -170,16 +182,23 @@ END
 	m.state = stateMock
 
 	stateMock.On("Reset")
-	stateMock.On("Execute")
 	stateMock.On("X").Return(int16(42))
 	stateMock.On("Y").Return(int16(420))
 	stateMock.On("Energy").Return(int16(17))
+	stateMock.On("Heading").Return(int16(91))
+	stateMock.On("Impact").Return(int16(0))
 	stateMock.On("Scan", int16(42), int16(420)).Return(int16(12), int16(34))
 	stateMock.On("Thrust", int16(-2), int16(134))
 	stateMock.On("Turn", int16(-420))
 	stateMock.On("Mine", int16(3))
 	stateMock.On("Reproduce", int16(4))
 	stateMock.On("Impulse", int16(-42))
+	stateMock.On("Give", int16(7))
+	stateMock.On("Take", int16(8))
+	stateMock.On("Dock")
+	stateMock.On("Undock")
+	stateMock.On("AttachTether")
+	stateMock.On("ReleaseTether")
 
 	m.Run()
 
@@ -227,14 +246,13 @@ END
 		return
 	}
 
-	m := NewMachine()
+	m := NewMachine(16)
 	m.run = runInstructionDebug
 	m.program = program
 	stateMock := &StateMock{}
 	m.state = stateMock
 
 	stateMock.On("Reset")
-	stateMock.On("Execute")
 	stateMock.On("Energy").Return(int16(1000))
 	stateMock.On("Reproduce", int16(500)).Once()
 	stateMock.On("X").Return(int16(42))
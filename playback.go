@@ -0,0 +1,166 @@
+package main
+
+import (
+	"math"
+	"sort"
+
+	"github.com/jakecoffman/cp"
+)
+
+type (
+	// Playback shows a recording frame by frame.
+	//
+	// Playback runs at the speed of the recorded game and
+	// can be paused, stepped and scrubbed to any frame.
+	Playback struct {
+		rec *Recording
+		// pos is the position in frames, the shown frame
+		// is its integer part
+		pos    float64
+		paused bool
+		// Speed is the playback speed relative to the
+		// recorded game
+		Speed float64
+
+		camera Camera
+		// parts are the convex parts of the asteroid objects
+		parts map[int32][][]cp.Vector
+
+		// frame is the rebuilt frame at index and poses its
+		// poses by object
+		frame Frame
+		index int
+		poses map[int32]Pose
+	}
+)
+
+// NewPlayback creates a playback of rec starting at the
+// first frame
+func NewPlayback(rec *Recording) *Playback {
+	p := &Playback{
+		rec:    rec,
+		Speed:  1,
+		camera: Camera{Zoom: 1},
+		parts:  make(map[int32][][]cp.Vector),
+		index:  -1,
+		poses:  make(map[int32]Pose),
+	}
+	for i, o := range rec.Objects {
+		if o.Kind == ObjectAsteroid {
+			p.parts[int32(i)] = decompose(o.Outline)
+		}
+	}
+	return p
+}
+
+// Len returns the number of frames
+func (p *Playback) Len() int {
+	return len(p.rec.Frames)
+}
+
+// Index returns the index of the shown frame
+func (p *Playback) Index() int {
+	return int(p.pos)
+}
+
+// Frame returns the shown frame with the poses of all
+// objects
+func (p *Playback) Frame() *Frame {
+	if p.Len() == 0 {
+		return &Frame{}
+	}
+	p.rebuild(p.Index())
+	return &p.frame
+}
+
+// rebuild rebuilds frame i from the shown frame if it is
+// before i, or else from the key frame before i
+func (p *Playback) rebuild(i int) {
+	if i == p.index {
+		return
+	}
+	// go on from the shown frame or start over at the key
+	// frame before i
+	start := p.index + 1
+	if i < start {
+		start = 0
+	}
+	for j := i; j > start; j-- {
+		if p.rec.Frames[j].Key {
+			start = j
+			break
+		}
+	}
+	if start != p.index+1 || p.rec.Frames[start].Key {
+		p.poses = make(map[int32]Pose)
+	}
+	for _, f := range p.rec.Frames[start : i+1] {
+		for _, id := range f.Removed {
+			delete(p.poses, id)
+		}
+		for _, m := range f.Moves {
+			p.poses[m.Object] = p.poses[m.Object].move(m)
+		}
+		for _, pose := range f.Poses {
+			p.poses[pose.Object] = pose
+		}
+	}
+
+	f := p.rec.Frames[i]
+	p.frame = Frame{
+		Tick:   f.Tick,
+		Key:    true,
+		Links:  f.Links,
+		Events: f.Events,
+		Poses:  make([]Pose, 0, len(p.poses)),
+	}
+	for _, pose := range p.poses {
+		p.frame.Poses = append(p.frame.Poses, pose)
+	}
+	sort.Slice(p.frame.Poses, func(i, j int) bool {
+		return p.frame.Poses[i].Object < p.frame.Poses[j].Object
+	})
+	p.index = i
+}
+
+// Object returns the recorded object of pose
+func (p *Playback) Object(pose Pose) RecordedObject {
+	return p.rec.Objects[pose.Object]
+}
+
+// Seek shows frame i. i is clamped to the recording.
+func (p *Playback) Seek(i int) {
+	p.pos = math.Max(0, math.Min(float64(i), float64(p.Len()-1)))
+}
+
+// Step moves n frames forward, or backward for n < 0
+func (p *Playback) Step(n int) {
+	p.Seek(p.Index() + n)
+}
+
+// TogglePause pauses or resumes playback
+func (p *Playback) TogglePause() {
+	p.paused = !p.paused
+}
+
+// Update advances the playback by the frame time dt
+func (p *Playback) Update(dt float32) {
+	if p.paused || p.Len() == 0 {
+		return
+	}
+	frameTime := timeStep * float64(p.rec.Every)
+	p.pos += math.Min(float64(dt), maxFrameTime) * p.Speed / frameTime
+	if last := float64(p.Len() - 1); p.pos > last {
+		p.pos = last
+	}
+}
+
+// toWorld returns the transformation of pose from body to
+// world coordinates
+func (pose Pose) toWorld() func(cp.Vector) cp.Vector {
+	pos := cp.Vector{X: float64(pose.X), Y: float64(pose.Y)}
+	rot := cp.ForAngle(float64(pose.Angle))
+	return func(v cp.Vector) cp.Vector {
+		return pos.Add(rot.Rotate(v))
+	}
+}
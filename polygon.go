@@ -0,0 +1,302 @@
+package main
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/jakecoffman/cp"
+)
+
+// polygonEpsilon is the distance below which two vertices
+// are considered equal
+const polygonEpsilon = 1e-6
+
+// decompose splits the simple polygon verts into convex
+// polygons.
+//
+// The polygon is triangulated by ear clipping, then
+// neighbouring triangles are merged as long as the result
+// stays convex (Hertel-Mehlhorn). All polygons are
+// counter-clockwise.
+func decompose(verts []cp.Vector) [][]cp.Vector {
+	verts = cleanPolygon(verts)
+	if len(verts) < 3 {
+		return nil
+	}
+	parts := mergeConvex(verts, triangulate(verts))
+	polys := make([][]cp.Vector, len(parts))
+	for i, part := range parts {
+		polys[i] = make([]cp.Vector, len(part))
+		for j, k := range part {
+			polys[i][j] = verts[k]
+		}
+	}
+	return polys
+}
+
+// cleanPolygon returns verts counter-clockwise and without
+// duplicate vertices
+func cleanPolygon(verts []cp.Vector) []cp.Vector {
+	out := make([]cp.Vector, 0, len(verts))
+	for _, v := range verts {
+		if len(out) > 0 && out[len(out)-1].Near(v, polygonEpsilon) {
+			continue
+		}
+		out = append(out, v)
+	}
+	for len(out) > 1 && out[0].Near(out[len(out)-1], polygonEpsilon) {
+		out = out[:len(out)-1]
+	}
+	if cp.AreaForPoly(len(out), out, 0) < 0 {
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
+		}
+	}
+	return out
+}
+
+// triangulate returns the triangles of the counter-clockwise
+// polygon verts as vertex indexes
+func triangulate(verts []cp.Vector) [][]int {
+	idx := make([]int, len(verts))
+	for i := range idx {
+		idx[i] = i
+	}
+	tris := make([][]int, 0, len(verts)-2)
+	for len(idx) > 3 {
+		n := len(idx)
+		ear := -1
+		// if there is no proper ear due to rounding, clip
+		// the least reflex vertex
+		flattest, maxCross := 0, math.Inf(-1)
+		for i := range idx {
+			a, b, c := verts[idx[(i+n-1)%n]], verts[idx[i]], verts[idx[(i+1)%n]]
+			cross := b.Sub(a).Cross(c.Sub(b))
+			if cross > maxCross {
+				flattest, maxCross = i, cross
+			}
+			if cross <= 0 {
+				continue
+			}
+			if isEar(verts, idx, i) {
+				ear = i
+				break
+			}
+		}
+		if ear < 0 {
+			ear = flattest
+		}
+		tris = append(tris, []int{idx[(ear+n-1)%n], idx[ear], idx[(ear+1)%n]})
+		idx = append(idx[:ear], idx[ear+1:]...)
+	}
+	return append(tris, idx)
+}
+
+// isEar returns true if no other vertex of the polygon lies
+// inside the triangle formed by vertex i and its neighbours
+func isEar(verts []cp.Vector, idx []int, i int) bool {
+	n := len(idx)
+	ia, ib, ic := idx[(i+n-1)%n], idx[i], idx[(i+1)%n]
+	a, b, c := verts[ia], verts[ib], verts[ic]
+	for _, j := range idx {
+		if j == ia || j == ib || j == ic {
+			continue
+		}
+		p := verts[j]
+		if b.Sub(a).Cross(p.Sub(a)) >= 0 &&
+			c.Sub(b).Cross(p.Sub(b)) >= 0 &&
+			a.Sub(c).Cross(p.Sub(c)) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeConvex merges polygons sharing an edge as long as
+// the result is convex
+func mergeConvex(verts []cp.Vector, polys [][]int) [][]int {
+	for merged := true; merged; {
+		merged = false
+		for i := 0; i < len(polys) && !merged; i++ {
+			for j := i + 1; j < len(polys) && !merged; j++ {
+				p, ok := mergePolygons(polys[i], polys[j])
+				if !ok || !isConvex(verts, p) {
+					continue
+				}
+				polys[i] = p
+				polys = append(polys[:j], polys[j+1:]...)
+				merged = true
+			}
+		}
+	}
+	return polys
+}
+
+// mergePolygons joins p and q along their shared edge
+func mergePolygons(p, q []int) ([]int, bool) {
+	np, nq := len(p), len(q)
+	for k := range p {
+		a, b := p[k], p[(k+1)%np]
+		for l := range q {
+			// shared edges run in opposite directions
+			if q[l] != b || q[(l+1)%nq] != a {
+				continue
+			}
+			out := make([]int, 0, np+nq-2)
+			// walk p from b to a, then q from after a to
+			// before b
+			for m := 1; m <= np; m++ {
+				out = append(out, p[(k+m)%np])
+			}
+			for m := 2; m < nq; m++ {
+				out = append(out, q[(l+m)%nq])
+			}
+			return out, true
+		}
+	}
+	return nil, false
+}
+
+// isConvex returns true if the polygon is convex and
+// counter-clockwise
+func isConvex(verts []cp.Vector, poly []int) bool {
+	n := len(poly)
+	for i := range poly {
+		a, b, c := verts[poly[i]], verts[poly[(i+1)%n]], verts[poly[(i+2)%n]]
+		if b.Sub(a).Cross(c.Sub(b)) < -polygonEpsilon {
+			return false
+		}
+	}
+	return true
+}
+
+// isSimple returns true if the counter-clockwise polygon
+// verts does not cross itself
+func isSimple(verts []cp.Vector) bool {
+	if cp.AreaForPoly(len(verts), verts, 0) <= 0 {
+		return false
+	}
+	verts = cleanPolygon(verts)
+	n := len(verts)
+	for i := 0; i < n; i++ {
+		a, b := verts[i], verts[(i+1)%n]
+		for j := i + 1; j < n; j++ {
+			// neighbouring edges share a vertex
+			if j == i+1 || (i == 0 && j == n-1) {
+				continue
+			}
+			if segmentsIntersect(a, b, verts[j], verts[(j+1)%n]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// segmentsIntersect returns true if the segments a-b and
+// c-d touch or cross
+func segmentsIntersect(a, b, c, d cp.Vector) bool {
+	side := func(p, q, r cp.Vector) float64 {
+		return q.Sub(p).Cross(r.Sub(p))
+	}
+	onSegment := func(p, q, r cp.Vector) bool {
+		return math.Min(p.X, q.X) <= r.X && r.X <= math.Max(p.X, q.X) &&
+			math.Min(p.Y, q.Y) <= r.Y && r.Y <= math.Max(p.Y, q.Y)
+	}
+	d1, d2 := side(c, d, a), side(c, d, b)
+	d3, d4 := side(a, b, c), side(a, b, d)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) &&
+		((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return (d1 == 0 && onSegment(c, d, a)) ||
+		(d2 == 0 && onSegment(c, d, b)) ||
+		(d3 == 0 && onSegment(a, b, c)) ||
+		(d4 == 0 && onSegment(a, b, d))
+}
+
+// splitPolygon splits the simple polygon verts along the line
+// through p in direction dir.
+//
+// Concave polygons can result in multiple polygons on each
+// side of the line.
+//
+// see https://stackoverflow.com/questions/3623703/how-can-i-split-a-polygon-by-a-line
+func splitPolygon(verts []cp.Vector, p, dir cp.Vector) (left, right [][]cp.Vector) {
+	side := func(v cp.Vector) float64 {
+		return dir.Cross(v.Sub(p))
+	}
+	type point struct {
+		v cp.Vector
+		// crossing points of the outline and the line are
+		// paired with the point on the other end of the cut
+		crossing bool
+		pair     int
+	}
+	n := len(verts)
+	points := make([]point, 0, n+4)
+	var crossings []int
+	for i, a := range verts {
+		b := verts[(i+1)%n]
+		sa, sb := side(a), side(b)
+		points = append(points, point{v: a})
+		if (sa >= 0) != (sb >= 0) {
+			crossings = append(crossings, len(points))
+			points = append(points, point{v: a.Lerp(b, sa/(sa-sb)), crossing: true})
+		}
+	}
+	if len(crossings) == 0 {
+		if side(verts[0]) >= 0 {
+			return [][]cp.Vector{verts}, nil
+		}
+		return nil, [][]cp.Vector{verts}
+	}
+	// along the line, the outline alternately enters and
+	// leaves the polygon
+	sort.SliceStable(crossings, func(i, j int) bool {
+		return dir.Dot(points[crossings[i]].v) < dir.Dot(points[crossings[j]].v)
+	})
+	for i := 0; i+1 < len(crossings); i += 2 {
+		points[crossings[i]].pair = crossings[i+1]
+		points[crossings[i+1]].pair = crossings[i]
+	}
+
+	visited := make([]bool, len(points))
+	for start := range points {
+		if visited[start] || points[start].crossing {
+			continue
+		}
+		var poly []cp.Vector
+		for i := start; len(poly) <= len(points); {
+			visited[i] = true
+			poly = append(poly, points[i].v)
+			if points[i].crossing {
+				i = points[i].pair
+				poly = append(poly, points[i].v)
+			}
+			i = (i + 1) % len(points)
+			if i == start {
+				break
+			}
+		}
+		if side(points[start].v) >= 0 {
+			left = append(left, poly)
+		} else {
+			right = append(right, poly)
+		}
+	}
+	return left, right
+}
+
+// polygonBounds returns the rectangle enclosing verts
+func polygonBounds(verts []cp.Vector) image.Rectangle {
+	bb := cp.NewBBForCircle(verts[0], 0)
+	for _, v := range verts {
+		bb = bb.Expand(v)
+	}
+	return image.Rect(
+		int(math.Floor(bb.L)), int(math.Floor(bb.B)),
+		int(math.Ceil(bb.R)), int(math.Ceil(bb.T)),
+	)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrPoolClosed is returned when running a closed pool
+var ErrPoolClosed = errors.New("worker pool closed")
+
+type (
+	// WorkerPool runs the machines of bots in parallel.
+	//
+	// The bots of each cycle are partitioned into one batch
+	// per worker. A cycle is never interrupted: once Run
+	// started, all machines run.
+	WorkerPool struct {
+		// mu is held while running a cycle, closing waits
+		// for the cycle to finish
+		mu     sync.Mutex
+		closed bool
+
+		// jobs has a channel per worker
+		jobs []chan []*Bot
+		// pending are the batches of the current cycle
+		pending sync.WaitGroup
+		workers sync.WaitGroup
+
+		stats []WorkerStats
+		// elapsed is the total time spent in Run
+		elapsed time.Duration
+	}
+
+	// WorkerStats are the statistics of a worker
+	WorkerStats struct {
+		// Busy is the time spent running machines
+		Busy time.Duration
+		// Bots is the number of machines run
+		Bots int64
+		// Utilisation (0..1) is the fraction of the time
+		// the worker was busy while the pool was running
+		Utilisation float64
+	}
+)
+
+// NewWorkerPool starts a pool of n workers
+func NewWorkerPool(n int) *WorkerPool {
+	p := &WorkerPool{
+		jobs:  make([]chan []*Bot, n),
+		stats: make([]WorkerStats, n),
+	}
+	p.workers.Add(n)
+	for i := range p.jobs {
+		p.jobs[i] = make(chan []*Bot, 1)
+		go p.work(i)
+	}
+	return p
+}
+
+func (p *WorkerPool) work(i int) {
+	defer p.workers.Done()
+	for batch := range p.jobs[i] {
+		start := time.Now()
+		for _, b := range batch {
+			b.machine.Run()
+			p.stats[i].Bots++
+		}
+		p.stats[i].Busy += time.Since(start)
+		p.pending.Done()
+	}
+}
+
+// Run runs the machines of all bots and waits until they
+// are done.
+//
+// Returns ErrPoolClosed if the pool was closed, no machine
+// ran in that case.
+func (p *WorkerPool) Run(bots []*Bot) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	start := time.Now()
+	n := len(p.jobs)
+	size := (len(bots) + n - 1) / n
+	for i := 0; i < n && i*size < len(bots); i++ {
+		end := (i + 1) * size
+		if end > len(bots) {
+			end = len(bots)
+		}
+		p.pending.Add(1)
+		p.jobs[i] <- bots[i*size : end]
+	}
+	p.pending.Wait()
+	p.elapsed += time.Since(start)
+	return nil
+}
+
+// Stats returns the statistics of each worker. It must not
+// be called concurrently with Run.
+func (p *WorkerPool) Stats() []WorkerStats {
+	stats := make([]WorkerStats, len(p.stats))
+	copy(stats, p.stats)
+	if p.elapsed > 0 {
+		for i := range stats {
+			stats[i].Utilisation = float64(stats[i].Busy) / float64(p.elapsed)
+		}
+	}
+	return stats
+}
+
+// Close stops all workers and waits for them to exit.
+//
+// Close may be called concurrently with Run, it waits for
+// the running cycle to finish. It is safe to call Close
+// more than once.
+func (p *WorkerPool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		for _, c := range p.jobs {
+			close(c)
+		}
+	}
+	p.mu.Unlock()
+	p.workers.Wait()
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBots(t testing.TB, n int) []*Bot {
+	t.Helper()
+	program, err := NewParser(strings.NewReader(`BEGIN EV
+	PSH CON 1
+END
+BEGIN EX
+	PSH CON 1
+	POP REG 0
+END
+`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp := cp.NewSpace()
+	hull := testHull(t)
+	bots := make([]*Bot, n)
+	for i := range bots {
+		bots[i] = NewBot(sp, int16(i), hull)
+		bots[i].machine.program = program
+	}
+	return bots
+}
+
+func TestWorkerPool(t *testing.T) {
+	p := NewWorkerPool(3)
+	defer p.Close()
+
+	// fewer bots than workers
+	for _, n := range []int{0, 2, 100} {
+		bots := testBots(t, n)
+		if !assert.NoError(t, p.Run(bots)) {
+			return
+		}
+		for _, b := range bots {
+			assert.Equal(t, int16(1), b.machine.registers[0])
+		}
+	}
+
+	stats := p.Stats()
+	if !assert.Len(t, stats, 3) {
+		return
+	}
+	var total int64
+	for _, s := range stats {
+		total += s.Bots
+		assert.GreaterOrEqual(t, s.Utilisation, 0.)
+		assert.LessOrEqual(t, s.Utilisation, 1.)
+	}
+	assert.Equal(t, int64(102), total)
+}
+
+func TestWorkerPoolClose(t *testing.T) {
+	p := NewWorkerPool(2)
+
+	// closing waits for the running cycle
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			bots := testBots(t, 100)
+			if err := p.Run(bots); err != nil {
+				assert.ErrorIs(t, err, ErrPoolClosed)
+				return
+			}
+			for _, b := range bots {
+				if !assert.Equal(t, int16(1), b.machine.registers[0]) {
+					return
+				}
+			}
+		}
+	}()
+	p.Close()
+	<-done
+
+	bots := testBots(t, 10)
+	assert.ErrorIs(t, p.Run(bots), ErrPoolClosed)
+	for _, b := range bots {
+		assert.Equal(t, int16(0), b.machine.registers[0])
+	}
+	// close may be called again
+	p.Close()
+}
+
+func TestGameCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g := &Game{ctx: ctx, seed: 1}
+	if !assert.NoError(t, g.Load("field")) {
+		return
+	}
+	defer g.Close()
+	g.cyclesPerTick = 3
+	g.Subscribe(func(e Event) {
+		// cancelled during the tick
+		if e.Type == EventTick && g.tick == 5 {
+			cancel()
+		}
+	})
+
+	stats, err := Simulate(g, 100)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int64(5), stats.Ticks)
+	assert.Equal(t, int64(15), stats.Steps)
+
+	// the statistics are of the last complete tick
+	other := &Game{seed: 1}
+	if !assert.NoError(t, other.Load("field")) {
+		return
+	}
+	defer other.Close()
+	other.cyclesPerTick = 3
+	for i := 0; i < 5; i++ {
+		other.Tick()
+	}
+	assert.Equal(t, other.Hash(), stats.Hash)
+}
+
+func BenchmarkWorkerPool(b *testing.B) {
+	bots := testBots(b, 10000)
+	p := NewWorkerPool(4)
+	defer p.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := p.Run(bots); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
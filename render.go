@@ -0,0 +1,393 @@
+//go:build !headless
+
+package main
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/jakecoffman/cp"
+)
+
+const (
+	// windoWidth is the base to determine the total rendered size of the screen
+	//
+	// The window is then scaled. The height is determined by retrieving the
+	// aspect ration of the screen size.
+	windowWidth = 1280
+
+	asteroidTextureFile = "gamedata/asteroid.png"
+)
+
+var (
+	asteroidColor        = rl.NewColor(0x80, 0x78, 0x70, 0xff)
+	asteroidOutlineColor = rl.NewColor(0xf0, 0xf0, 0xf0, 0xff)
+	dockColor            = rl.SkyBlue
+	tetherColor          = rl.Orange
+	debrisColor          = rl.Gray
+	wallColor            = rl.DarkGray
+	boundsColor          = rl.DarkBlue
+	wellColor            = rl.Gold
+
+	timelineColor         = rl.DarkGray
+	timelineProgressColor = rl.LightGray
+)
+
+type (
+	// Renderer draws the game with raylib.
+	//
+	// The game itself does not depend on raylib and can run
+	// without a window.
+	Renderer struct {
+		// width and height of the game scene in pixels
+		w, h int
+
+		textures struct {
+			asteroid rl.Texture2D
+			// sprites are loaded when first drawn
+			sprites map[string]rl.Texture2D
+		}
+	}
+)
+
+// NewRenderer opens the window
+func NewRenderer() *Renderer {
+	r := &Renderer{}
+	w, h := rl.GetScreenWidth(), rl.GetScreenHeight()
+	ratio := float64(w) / float64(h)
+	r.w = windowWidth
+	r.h = int(float64(r.w) / ratio)
+
+	rl.InitWindow(int32(r.w), int32(r.h), title)
+
+	r.textures.asteroid = rl.LoadTexture(asteroidTextureFile)
+	rl.SetTextureWrap(r.textures.asteroid, rl.WrapRepeat)
+	r.textures.sprites = make(map[string]rl.Texture2D)
+
+	return r
+}
+
+// Close releases all resources and closes the window
+func (r *Renderer) Close() {
+	rl.UnloadTexture(r.textures.asteroid)
+	for _, tex := range r.textures.sprites {
+		rl.UnloadTexture(tex)
+	}
+	rl.CloseWindow()
+}
+
+// HandleInput controls time and the camera of g.
+//
+// Space pauses, Period runs a single tick, the brackets
+// change the speed. Comma rewinds one tick and Backspace
+// one second.
+func (r *Renderer) HandleInput(g *Game) {
+	r.moveCamera(&g.camera)
+
+	var err error
+	switch {
+	case rl.IsKeyPressed(rl.KeySpace):
+		g.SetPaused(!g.Paused())
+	case rl.IsKeyPressed(rl.KeyPeriod):
+		g.SingleStep()
+	case rl.IsKeyPressed(rl.KeyRightBracket):
+		g.SetSpeed(g.Speed() * 2)
+	case rl.IsKeyPressed(rl.KeyLeftBracket):
+		g.SetSpeed(g.Speed() / 2)
+	case rl.IsKeyPressed(rl.KeyComma):
+		err = g.RewindBy(1)
+	case rl.IsKeyPressed(rl.KeyBackspace):
+		err = g.RewindBy(int64(1 / timeStep))
+	}
+	if err != nil {
+		errLog.Log("msg", "error rewinding", "err", err)
+	}
+}
+
+// moveCamera pans c with the right mouse button and zooms
+// with the mouse wheel
+func (r *Renderer) moveCamera(c *Camera) {
+	if rl.IsMouseButtonDown(rl.MouseRightButton) {
+		delta := rl.GetMouseDelta()
+		c.Target = c.Target.Sub(vector(delta).Mult(1 / c.Zoom))
+	}
+
+	wheel := rl.GetMouseWheelMove()
+	if wheel != 0 {
+		mouseWorldPos := rl.GetScreenToWorld2D(rl.GetMousePosition(), camera2D(*c))
+		c.Offset = vector(rl.GetMousePosition())
+		c.Target = vector(mouseWorldPos)
+		const zoomIncrement = 0.0125
+		c.Zoom += zoomIncrement * float64(wheel)
+		if c.Zoom <= 0 {
+			c.Zoom = zoomIncrement
+		}
+	}
+}
+
+// Draw renders a frame of g
+func (r *Renderer) Draw(g *Game) {
+	rl.BeginDrawing()
+
+	rl.ClearBackground(rl.Black)
+
+	rl.BeginMode2D(camera2D(g.camera))
+	drawWorld(g.world)
+	for _, w := range g.wells {
+		r.drawWell(w.body.Position(), w.body.Angle(), w.Radius, w.Sprite)
+	}
+	for _, b := range g.bots {
+		drawBot(b.Position(), b.Rotation(), b.radius)
+	}
+	for _, d := range g.docks {
+		rl.DrawLineV(vec2(d.a.Position()), vec2(d.b.Position()), dockColor)
+	}
+	for _, t := range g.tethers {
+		rl.DrawLineV(vec2(t.bot.Position()), vec2(t.AnchorPosition()), tetherColor)
+	}
+	for _, d := range g.debris {
+		rl.DrawCircleV(vec2(d.Position()), float32(d.Radius()), debrisColor)
+	}
+	for _, a := range g.asteroids {
+		var parts [][]cp.Vector
+		a.Body.EachShape(func(s *cp.Shape) {
+			poly, ok := s.Class.(*cp.PolyShape)
+			if !ok {
+				return
+			}
+			part := make([]cp.Vector, poly.Count())
+			for i := range part {
+				part[i] = poly.Vert(i)
+			}
+			parts = append(parts, part)
+		})
+		r.drawAsteroid(parts, a.Outline, a.LocalToWorld)
+	}
+	rl.EndMode2D()
+
+	status := fmt.Sprintf("tick %d  x%d", g.tick, g.speed)
+	if g.paused {
+		status += "  paused"
+	}
+	rl.DrawText(status, 10, 10, 10, rl.White)
+
+	rl.EndDrawing()
+}
+
+// HandlePlaybackInput controls the playback p.
+//
+// Space pauses, the arrow keys step frames, Home and End
+// jump to the start and the end. Clicking or dragging on
+// the timeline scrubs.
+func (r *Renderer) HandlePlaybackInput(p *Playback) {
+	r.moveCamera(&p.camera)
+
+	switch {
+	case rl.IsKeyPressed(rl.KeySpace):
+		p.TogglePause()
+	case rl.IsKeyPressed(rl.KeyRight):
+		p.Step(1)
+	case rl.IsKeyPressed(rl.KeyLeft):
+		p.Step(-1)
+	case rl.IsKeyPressed(rl.KeyHome):
+		p.Seek(0)
+	case rl.IsKeyPressed(rl.KeyEnd):
+		p.Seek(p.Len() - 1)
+	}
+
+	if rl.IsMouseButtonDown(rl.MouseLeftButton) {
+		mouse := rl.GetMousePosition()
+		if rl.CheckCollisionPointRec(mouse, r.timeline()) {
+			frac := float64(mouse.X) / float64(r.w)
+			p.Seek(int(math.Round(frac * float64(p.Len()-1))))
+		}
+	}
+}
+
+// DrawPlayback renders the shown frame of p and the
+// timeline
+func (r *Renderer) DrawPlayback(p *Playback) {
+	rl.BeginDrawing()
+
+	rl.ClearBackground(rl.Black)
+
+	f := p.Frame()
+	rl.BeginMode2D(camera2D(p.camera))
+	drawWorld(p.rec.World)
+	for _, pose := range f.Poses {
+		o := p.Object(pose)
+		toWorld := pose.toWorld()
+		switch o.Kind {
+		case ObjectWell:
+			r.drawWell(toWorld(cp.Vector{}), float64(pose.Angle), o.Radius, o.Sprite)
+		case ObjectBot:
+			drawBot(toWorld(cp.Vector{}), cp.ForAngle(float64(pose.Angle)), o.Radius)
+		case ObjectDebris:
+			rl.DrawCircleV(vec2(toWorld(cp.Vector{})), float32(o.Radius), debrisColor)
+		}
+	}
+	for _, l := range f.Links {
+		color := dockColor
+		if l.Kind == LinkTether {
+			color = tetherColor
+		}
+		rl.DrawLineV(rl.Vector2{X: l.X1, Y: l.Y1}, rl.Vector2{X: l.X2, Y: l.Y2}, color)
+	}
+	for _, pose := range f.Poses {
+		if o := p.Object(pose); o.Kind == ObjectAsteroid {
+			r.drawAsteroid(p.parts[pose.Object], o.Outline, pose.toWorld())
+		}
+	}
+	rl.EndMode2D()
+
+	bar := r.timeline()
+	rl.DrawRectangleRec(bar, timelineColor)
+	if p.Len() > 1 {
+		bar.Width *= float32(p.Index()) / float32(p.Len()-1)
+	}
+	rl.DrawRectangleRec(bar, timelineProgressColor)
+	rl.DrawText(fmt.Sprintf("tick %d", f.Tick), 10, int32(bar.Y)-20, 10, rl.White)
+
+	rl.EndDrawing()
+}
+
+// timeline returns the area of the playback timeline
+func (r *Renderer) timeline() rl.Rectangle {
+	const height = 8
+	return rl.Rectangle{X: 0, Y: float32(r.h - height), Width: float32(r.w), Height: height}
+}
+
+// drawWorld renders the walls or the wrap-around bounds
+// of w
+func drawWorld(w World) {
+	bb := w.Bounds
+	rect := rl.Rectangle{
+		X:      float32(bb.L),
+		Y:      float32(bb.B),
+		Width:  float32(bb.R - bb.L),
+		Height: float32(bb.T - bb.B),
+	}
+	switch w.Kind {
+	case WorldWalled:
+		// walls are centered on the bounds
+		rect.X -= wallRadius
+		rect.Y -= wallRadius
+		rect.Width += 2 * wallRadius
+		rect.Height += 2 * wallRadius
+		rl.DrawRectangleLinesEx(rect, 2*wallRadius, wallColor)
+	case WorldToroidal:
+		rl.DrawRectangleLinesEx(rect, 1, boundsColor)
+	}
+}
+
+// drawWell renders a planet or sun with its sprite, or
+// as a circle if the sprite can not be loaded
+func (r *Renderer) drawWell(pos cp.Vector, angle, radius float64, sprite string) {
+	tex := r.sprite(sprite)
+	if !rl.IsTextureValid(tex) {
+		rl.DrawCircleV(vec2(pos), float32(radius), wellColor)
+		return
+	}
+	size := float32(2 * radius)
+	rl.DrawTexturePro(
+		tex,
+		rl.Rectangle{Width: float32(tex.Width), Height: float32(tex.Height)},
+		rl.Rectangle{X: float32(pos.X), Y: float32(pos.Y), Width: size, Height: size},
+		rl.Vector2{X: size / 2, Y: size / 2},
+		float32(angle*180/math.Pi),
+		rl.White,
+	)
+}
+
+// sprite returns the texture loaded from file path
+func (r *Renderer) sprite(path string) rl.Texture2D {
+	tex, ok := r.textures.sprites[path]
+	if !ok && path != "" {
+		tex = rl.LoadTexture(path)
+		r.textures.sprites[path] = tex
+	}
+	return tex
+}
+
+// drawBot renders a bot with a line showing its heading
+func drawBot(pos, rot cp.Vector, radius float64) {
+	heading := pos.Add(rot.Mult(radius))
+	rl.DrawCircleV(vec2(pos), float32(radius), rl.White)
+	rl.DrawLineV(vec2(pos), vec2(heading), rl.Black)
+}
+
+// drawAsteroid renders the asteroid's convex parts filled
+// and its outline. Parts and outline are in body
+// coordinates, toWorld transforms them to world
+// coordinates.
+//
+// The asteroid texture is used as fill if it is loaded.
+func (r *Renderer) drawAsteroid(parts [][]cp.Vector, outline []cp.Vector, toWorld func(cp.Vector) cp.Vector) {
+	tex := r.textures.asteroid
+	textured := rl.IsTextureValid(tex)
+	for _, part := range parts {
+		n := len(part)
+		points := make([]rl.Vector2, n)
+		texcoords := make([]rl.Vector2, n)
+		// raylib expects vertices counter-clockwise on screen,
+		// that is clockwise in chipmunk's coordinates
+		for i := 0; i < n; i++ {
+			local := part[n-1-i]
+			points[i] = vec2(toWorld(local))
+			texcoords[i] = rl.Vector2{
+				X: float32(local.X) / float32(tex.Width),
+				Y: float32(local.Y) / float32(tex.Height),
+			}
+		}
+		if textured {
+			drawTexturedFan(tex, points, texcoords, rl.White)
+		} else {
+			rl.DrawTriangleFan(points, asteroidColor)
+		}
+	}
+	points := make([]rl.Vector2, 0, len(outline)+1)
+	for _, v := range outline {
+		points = append(points, vec2(toWorld(v)))
+	}
+	if len(points) > 0 {
+		rl.DrawLineStrip(append(points, points[0]), asteroidOutlineColor)
+	}
+}
+
+// drawTexturedFan draws a convex polygon as triangle fan
+// filled with texture
+func drawTexturedFan(tex rl.Texture2D, points, texcoords []rl.Vector2, tint rl.Color) {
+	rl.SetTexture(tex.ID)
+	// raylib batches quads, each triangle is drawn as
+	// a quad with its last vertex repeated
+	rl.Begin(rl.Quads)
+	rl.Color4ub(tint.R, tint.G, tint.B, tint.A)
+	for i := 1; i < len(points)-1; i++ {
+		for _, j := range []int{0, i, i + 1, i + 1} {
+			rl.TexCoord2f(texcoords[j].X, texcoords[j].Y)
+			rl.Vertex2f(points[j].X, points[j].Y)
+		}
+	}
+	rl.End()
+	rl.SetTexture(0)
+}
+
+// vec2 converts a chipmunk vector to raylib
+func vec2(v cp.Vector) rl.Vector2 {
+	return rl.Vector2{X: float32(v.X), Y: float32(v.Y)}
+}
+
+// vector converts a raylib vector to chipmunk
+func vector(v rl.Vector2) cp.Vector {
+	return cp.Vector{X: float64(v.X), Y: float64(v.Y)}
+}
+
+// camera2D converts the game's camera to raylib
+func camera2D(c Camera) rl.Camera2D {
+	return rl.Camera2D{
+		Target: vec2(c.Target),
+		Offset: vec2(c.Offset),
+		Zoom:   float32(c.Zoom),
+	}
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/jakecoffman/cp"
+	"github.com/pkg/errors"
+)
+
+// recordingVersion is the version of the recording format
+const recordingVersion = 2
+
+const (
+	// keyFrameInterval is the number of frames between key
+	// frames
+	keyFrameInterval = 300
+	// movePrecision is the step of moves in pixels
+	movePrecision = 1. / 64
+	// moveAnglePrecision is the step of moves in radians
+	moveAnglePrecision = 1. / 4096
+	// maxMoveSteps is the largest move in steps, objects
+	// moving further get a pose
+	maxMoveSteps = 1 << 30
+)
+
+const (
+	ObjectBot ObjectKind = iota
+	ObjectAsteroid
+	ObjectDebris
+	ObjectWell
+)
+
+const (
+	LinkDock LinkKind = iota
+	LinkTether
+)
+
+type (
+	ObjectKind uint8
+	LinkKind   uint8
+
+	// Recording is a run of a game as seen on screen.
+	//
+	// What does not change about an object, such as its
+	// outline, is recorded once. Every keyFrameInterval-th
+	// frame is a key frame with the poses of all objects, the
+	// frames in between only hold what changed. Playback
+	// rebuilds frames from the key frame before them, so they
+	// can be shown in any order without simulating the game.
+	Recording struct {
+		Version  int
+		Scenario string
+		Seed     int64
+		// Every is the number of ticks between frames
+		Every int
+		World World
+
+		Objects []RecordedObject
+		Frames  []Frame
+	}
+
+	// RecordedObject is the part of an object that does
+	// not change
+	RecordedObject struct {
+		Kind ObjectKind
+		// BotID of bots
+		BotID int16
+		// Radius of bots, debris and wells
+		Radius float64
+		// Outline of asteroids in body coordinates
+		Outline []cp.Vector
+		// Sprite of wells
+		Sprite string
+	}
+
+	// Frame is the state of the world at Tick.
+	//
+	// Key frames hold the poses of all objects. The other
+	// frames hold the poses of new objects, the moves of the
+	// objects that moved and the objects that were removed
+	// since the previous frame.
+	Frame struct {
+		Tick    int64
+		Key     bool
+		Poses   []Pose
+		Moves   []Move
+		Removed []int32
+		Links   []Link
+		// Events since the previous frame
+		Events []Event
+	}
+
+	// Pose is the placement of an object in a frame.
+	// Object is the index in Recording.Objects.
+	Pose struct {
+		Object      int32
+		X, Y, Angle float32
+	}
+
+	// Move is the change of the pose of an object since the
+	// previous frame, in steps of movePrecision and
+	// moveAnglePrecision
+	Move struct {
+		Object         int32
+		DX, DY, DAngle int32
+	}
+
+	// Link is a dock or tether between two points
+	Link struct {
+		Kind           LinkKind
+		X1, Y1, X2, Y2 float32
+	}
+
+	// Recorder records a game into a recording file.
+	//
+	// The recorder follows the game's events and writes a
+	// frame every Every ticks as the game runs, so memory
+	// does not grow with the length of the run. The output
+	// is flushed at every key frame, so a recording cut off
+	// by a crash can be read up to the last key frame.
+	//
+	// When the game is restored or rewound, the recorder
+	// writes a key frame of the new state. Reading the
+	// recording drops the frames it goes back over, so the
+	// ticks of the frames always increase.
+	Recorder struct {
+		zw  *gzip.Writer
+		enc *gob.Encoder
+		// file is closed with the recorder if the recorder
+		// created it
+		file io.Closer
+		// err is the first error writing the recording
+		err error
+
+		objects int32
+		// frames is the number of frames since the last
+		// key frame
+		frames int
+		// shown are the poses of the objects of the last
+		// frame as playback shows them
+		shown  map[interface{}]Pose
+		events []Event
+		paused bool
+	}
+
+	// recordedFrame is a frame with the objects first seen
+	// in it, as written after the recording's header
+	recordedFrame struct {
+		Objects []RecordedObject
+		Frame   Frame
+	}
+)
+
+// NewRecorder starts recording g to w with a frame every
+// `every` ticks. The current state is the first frame.
+// The recording is complete once the recorder is closed.
+func NewRecorder(g *Game, every int, w io.Writer) *Recorder {
+	if every <= 0 {
+		every = 1
+	}
+	r := &Recorder{
+		zw:    gzip.NewWriter(w),
+		shown: make(map[interface{}]Pose),
+	}
+	r.enc = gob.NewEncoder(r.zw)
+	r.write(&Recording{
+		Version:  recordingVersion,
+		Scenario: g.scenario,
+		Seed:     g.seed,
+		Every:    every,
+		World:    g.world,
+	})
+	r.record(g)
+	g.Subscribe(func(e Event) {
+		if r.paused {
+			return
+		}
+		switch e.Type {
+		case EventRestore, EventRewind:
+			r.restart(g)
+			return
+		case EventTick:
+		default:
+			r.events = append(r.events, e)
+			return
+		}
+		if g.tick%int64(every) == 0 {
+			r.record(g)
+		}
+	})
+	return r
+}
+
+// RecordFile starts recording g to the file path
+func RecordFile(g *Game, every int, path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating recording file")
+	}
+	r := NewRecorder(g, every, f)
+	r.file = f
+	return r, nil
+}
+
+// Stop stops recording
+func (r *Recorder) Stop() {
+	r.paused = true
+}
+
+// Close stops recording and completes the recording. It
+// returns the first error writing it.
+func (r *Recorder) Close() error {
+	if r.zw == nil {
+		return r.err
+	}
+	r.Stop()
+	if err := r.zw.Close(); err != nil && r.err == nil {
+		r.err = errors.Wrap(err, "error compressing recording")
+	}
+	r.zw = nil
+	if r.file != nil {
+		if err := r.file.Close(); err != nil && r.err == nil {
+			r.err = errors.Wrap(err, "error writing recording file")
+		}
+	}
+	return r.err
+}
+
+// write encodes v. After an error nothing is written.
+func (r *Recorder) write(v interface{}) {
+	if r.err != nil {
+		return
+	}
+	if err := r.enc.Encode(v); err != nil {
+		r.err = errors.Wrap(err, "error encoding recording")
+	}
+}
+
+// restart writes a key frame of the restored or rewound
+// game. The objects are new, so they are recorded again.
+func (r *Recorder) restart(g *Game) {
+	r.shown = make(map[interface{}]Pose)
+	r.events = nil
+	r.frames = 0
+	r.record(g)
+}
+
+// record writes a frame with the state of g
+func (r *Recorder) record(g *Game) {
+	rf := recordedFrame{
+		Frame: Frame{
+			Tick:   g.tick,
+			Key:    r.frames == 0,
+			Events: r.events,
+		},
+	}
+	f := &rf.Frame
+	r.events = nil
+	r.frames = (r.frames + 1) % keyFrameInterval
+
+	shown := make(map[interface{}]Pose, len(r.shown))
+	pose := func(key interface{}, b *cp.Body, object func() RecordedObject) {
+		last, ok := r.shown[key]
+		delete(r.shown, key)
+		if !ok {
+			last.Object = r.objects
+			r.objects++
+			rf.Objects = append(rf.Objects, object())
+		}
+		p := b.Position()
+		pose := Pose{
+			Object: last.Object,
+			X:      float32(p.X),
+			Y:      float32(p.Y),
+			Angle:  float32(b.Angle()),
+		}
+		if ok && !f.Key {
+			if m, ok := moveTo(last, pose); ok {
+				// playback shows the moved pose, so later
+				// moves start from it and errors do not
+				// add up
+				if m != (Move{Object: m.Object}) {
+					f.Moves = append(f.Moves, m)
+				}
+				shown[key] = last.move(m)
+				return
+			}
+		}
+		f.Poses = append(f.Poses, pose)
+		shown[key] = pose
+	}
+	for _, b := range g.bots {
+		pose(b, b.Body, func() RecordedObject {
+			return RecordedObject{Kind: ObjectBot, BotID: b.id, Radius: b.radius}
+		})
+	}
+	for _, a := range g.asteroids {
+		pose(a, a.Body, func() RecordedObject {
+			return RecordedObject{Kind: ObjectAsteroid, Outline: a.Outline}
+		})
+	}
+	for _, d := range g.debris {
+		pose(d, d.Body, func() RecordedObject {
+			return RecordedObject{Kind: ObjectDebris, Radius: d.Radius()}
+		})
+	}
+	for _, w := range g.wells {
+		pose(w, w.body, func() RecordedObject {
+			return RecordedObject{Kind: ObjectWell, Radius: w.Radius, Sprite: w.Sprite}
+		})
+	}
+	// what is left was removed
+	if !f.Key {
+		for _, last := range r.shown {
+			f.Removed = append(f.Removed, last.Object)
+		}
+		sort.Slice(f.Removed, func(i, j int) bool { return f.Removed[i] < f.Removed[j] })
+	}
+	r.shown = shown
+
+	for _, d := range g.docks {
+		f.Links = append(f.Links, link(LinkDock, d.a.Position(), d.b.Position()))
+	}
+	for _, t := range g.tethers {
+		f.Links = append(f.Links, link(LinkTether, t.bot.Position(), t.AnchorPosition()))
+	}
+	r.write(&rf)
+	if f.Key && r.err == nil {
+		r.err = errors.Wrap(r.zw.Flush(), "error compressing recording")
+	}
+}
+
+// moveTo returns the move from pose a towards b. It is
+// false if b is too far to move to.
+func moveTo(a, b Pose) (Move, bool) {
+	dx := math.Round((float64(b.X) - float64(a.X)) / movePrecision)
+	dy := math.Round((float64(b.Y) - float64(a.Y)) / movePrecision)
+	da := math.Round((float64(b.Angle) - float64(a.Angle)) / moveAnglePrecision)
+	if !(math.Abs(dx) <= maxMoveSteps && math.Abs(dy) <= maxMoveSteps && math.Abs(da) <= maxMoveSteps) {
+		return Move{}, false
+	}
+	return Move{Object: a.Object, DX: int32(dx), DY: int32(dy), DAngle: int32(da)}, true
+}
+
+// move returns the pose after the move m
+func (pose Pose) move(m Move) Pose {
+	return Pose{
+		Object: pose.Object,
+		X:      pose.X + float32(float64(m.DX)*movePrecision),
+		Y:      pose.Y + float32(float64(m.DY)*movePrecision),
+		Angle:  pose.Angle + float32(float64(m.DAngle)*moveAnglePrecision),
+	}
+}
+
+func link(kind LinkKind, a, b cp.Vector) Link {
+	return Link{
+		Kind: kind,
+		X1:   float32(a.X),
+		Y1:   float32(a.Y),
+		X2:   float32(b.X),
+		Y2:   float32(b.Y),
+	}
+}
+
+// ReadRecording reads a recording written by a Recorder.
+// A recording that was cut off is read up to its last
+// complete frame.
+func ReadRecording(r io.Reader) (*Recording, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decompressing recording")
+	}
+	defer zr.Close()
+	dec := gob.NewDecoder(zr)
+	rec := &Recording{}
+	if err := dec.Decode(rec); err != nil {
+		return nil, errors.Wrap(err, "error decoding recording")
+	}
+	if rec.Version != recordingVersion {
+		return nil, errors.Errorf("unsupported recording version %d", rec.Version)
+	}
+	for {
+		var rf recordedFrame
+		err := dec.Decode(&rf)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return rec, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "error decoding recording")
+		}
+		rec.Objects = append(rec.Objects, rf.Objects...)
+		// the key frame after a rewind replaces the frames
+		// at and after its tick
+		n := len(rec.Frames)
+		if n > 0 && rf.Frame.Tick <= rec.Frames[n-1].Tick {
+			n = sort.Search(n, func(i int) bool {
+				return rec.Frames[i].Tick >= rf.Frame.Tick
+			})
+			rec.Frames = rec.Frames[:n]
+		}
+		rec.Frames = append(rec.Frames, rf.Frame)
+	}
+}
+
+// ReadRecordingFile reads the recording from the file path
+func ReadRecordingFile(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening recording file")
+	}
+	defer f.Close()
+	return ReadRecording(f)
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	g, err := NewGame("all", 42)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer g.Close()
+
+	var buf bytes.Buffer
+	rec := NewRecorder(g, 5, &buf)
+	for i := 0; i < 50; i++ {
+		g.Tick()
+	}
+	rec.Stop()
+	g.Tick()
+	if !assert.NoError(t, rec.Close()) {
+		return
+	}
+
+	r, err := ReadRecording(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "all", r.Scenario)
+	assert.Equal(t, int64(42), r.Seed)
+	if !assert.Len(t, r.Frames, 11) {
+		return
+	}
+	for i, f := range r.Frames {
+		assert.Equal(t, int64(i*5), f.Tick)
+	}
+
+	// the last frame shows the world at tick 50
+	p := NewPlayback(r)
+	p.Seek(10)
+	last := p.Frame()
+	assert.Len(t, last.Poses, len(g.bots)+len(g.asteroids)+len(g.debris))
+	for _, pose := range last.Poses {
+		o := r.Objects[pose.Object]
+		if o.Kind != ObjectBot {
+			continue
+		}
+		for _, b := range g.bots {
+			if b.id == o.BotID {
+				assert.Equal(t, b.radius, o.Radius)
+			}
+		}
+	}
+
+	// objects are recorded once
+	assert.Len(t, r.Objects, len(last.Poses))
+}
+
+func TestRecorderEvents(t *testing.T) {
+	g, err := NewGame("asteroid", 1)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer g.Close()
+
+	var buf bytes.Buffer
+	rec := NewRecorder(g, 1, &buf)
+	a := g.asteroids[0]
+	g.fracture(a, a.Position(), a.Rotation())
+	g.Tick()
+	if !assert.NoError(t, rec.Close()) {
+		return
+	}
+
+	r, err := ReadRecording(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, r.Frames, 2) {
+		return
+	}
+	if assert.Len(t, r.Frames[1].Events, 1) {
+		assert.Equal(t, EventAsteroidFracture, r.Frames[1].Events[0].Type)
+	}
+	// the fragments are new objects
+	assert.Len(t, g.asteroids, 3)
+	assert.Len(t, r.Frames[1].Poses, 2)
+	assert.Equal(t, []int32{0}, r.Frames[1].Removed)
+	assert.Len(t, r.Objects, 4)
+	p := NewPlayback(r)
+	p.Seek(1)
+	assert.Len(t, p.Frame().Poses, 3)
+}
+
+func TestRecordingFrames(t *testing.T) {
+	g, err := NewGame("field", 42)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer g.Close()
+
+	var buf bytes.Buffer
+	rec := NewRecorder(g, 1, &buf)
+	// the poses of all objects at each tick
+	var want [][]Pose
+	poses := func() {
+		var f []Pose
+		for o, shown := range rec.shown {
+			var b *cp.Body
+			switch o := o.(type) {
+			case *Bot:
+				b = o.Body
+			case *Asteroid:
+				b = o.Body
+			case *Debris:
+				b = o.Body
+			case *GravityWell:
+				b = o.body
+			}
+			p := b.Position()
+			f = append(f, Pose{Object: shown.Object, X: float32(p.X), Y: float32(p.Y), Angle: float32(b.Angle())})
+		}
+		sort.Slice(f, func(i, j int) bool { return f[i].Object < f[j].Object })
+		want = append(want, f)
+	}
+	poses()
+	g.Subscribe(func(e Event) {
+		if e.Type == EventTick {
+			poses()
+		}
+	})
+	ticks := 2*keyFrameInterval + 100
+	for i := 0; i < ticks; i++ {
+		g.Tick()
+	}
+	if !assert.NoError(t, rec.Close()) {
+		return
+	}
+	size := buf.Len()
+
+	r, err := ReadRecording(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, r.Frames, ticks+1) {
+		return
+	}
+	// most objects are moved, not placed
+	var placed, moved int
+	for _, f := range r.Frames {
+		if !f.Key {
+			placed += len(f.Poses)
+			moved += len(f.Moves)
+		}
+	}
+	assert.Greater(t, moved, 10*placed)
+	assert.Less(t, size, (ticks+1)*len(want[ticks])*4)
+
+	// frames are the same in any order
+	p := NewPlayback(r)
+	for _, i := range []int{0, 1, 2, ticks, keyFrameInterval + 1, keyFrameInterval - 1, 2 * keyFrameInterval, 5} {
+		p.Seek(i)
+		got := p.Frame()
+		if !assert.Len(t, got.Poses, len(want[i]), "frame %d", i) {
+			return
+		}
+		for j, pose := range got.Poses {
+			w := want[i][j]
+			assert.Equal(t, w.Object, pose.Object)
+			assert.InDelta(t, w.X, pose.X, movePrecision, "frame %d", i)
+			assert.InDelta(t, w.Y, pose.Y, movePrecision, "frame %d", i)
+			assert.InDelta(t, w.Angle, pose.Angle, moveAnglePrecision, "frame %d", i)
+		}
+	}
+
+	_, err = ReadRecording(bytes.NewReader([]byte("moonshot")))
+	assert.Error(t, err)
+}
+
+func TestReadCutOffRecording(t *testing.T) {
+	g, err := NewGame("field", 42)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer g.Close()
+
+	var buf bytes.Buffer
+	rec := NewRecorder(g, 1, &buf)
+	defer rec.Close()
+	for i := 0; i < keyFrameInterval+10; i++ {
+		g.Tick()
+	}
+
+	// the recording was not closed, as after a crash, and
+	// is read up to the last key frame
+	r, err := ReadRecording(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.GreaterOrEqual(t, len(r.Frames), keyFrameInterval+1)
+	p := NewPlayback(r)
+	p.Seek(keyFrameInterval)
+	assert.NotEmpty(t, p.Frame().Poses)
+}
+
+func TestRecorderRewind(t *testing.T) {
+	g, err := NewGame("field", 42)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer g.Close()
+	if !assert.NoError(t, g.EnableHistory(10, 20)) {
+		return
+	}
+
+	var buf bytes.Buffer
+	rec := NewRecorder(g, 1, &buf)
+	for i := 0; i < 100; i++ {
+		g.Tick()
+	}
+	if !assert.NoError(t, g.Rewind(50)) {
+		return
+	}
+	objects := len(g.asteroids) + len(g.debris) + len(g.wells)
+	for i := 0; i < 20; i++ {
+		g.Tick()
+	}
+	if !assert.NoError(t, rec.Close()) {
+		return
+	}
+
+	// the frames after the rewind replace the frames it
+	// went back over
+	r, err := ReadRecording(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, r.Frames, 71) {
+		return
+	}
+	for i, f := range r.Frames {
+		assert.Equal(t, int64(i), f.Tick)
+	}
+	assert.True(t, r.Frames[50].Key)
+	p := NewPlayback(r)
+	p.Seek(50)
+	assert.Len(t, p.Frame().Poses, objects)
+}
+
+func TestPlayback(t *testing.T) {
+	rec := &Recording{Every: 2}
+	for i := 0; i < 10; i++ {
+		rec.Frames = append(rec.Frames, Frame{Tick: int64(2 * i)})
+	}
+	p := NewPlayback(rec)
+	assert.Equal(t, 10, p.Len())
+	assert.Equal(t, int64(0), p.Frame().Tick)
+
+	// a frame every two ticks
+	p.Update(2 * timeStep)
+	assert.Equal(t, 1, p.Index())
+	p.Update(timeStep)
+	assert.Equal(t, 1, p.Index())
+	p.Update(timeStep)
+	assert.Equal(t, 2, p.Index())
+
+	p.TogglePause()
+	p.Update(10 * timeStep)
+	assert.Equal(t, 2, p.Index())
+
+	p.Step(3)
+	assert.Equal(t, int64(10), p.Frame().Tick)
+	p.Step(-10)
+	assert.Equal(t, 0, p.Index())
+	p.Seek(100)
+	assert.Equal(t, 9, p.Index())
+
+	// playback stops at the end
+	p.TogglePause()
+	p.Update(maxFrameTime)
+	assert.Equal(t, 9, p.Index())
+}
@@ -0,0 +1,544 @@
+package main
+
+import (
+	"encoding/json"
+	"image"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/jakecoffman/cp"
+	"github.com/pkg/errors"
+)
+
+// saveVersion is the version of the save format. Saves of
+// other versions can not be loaded.
+const saveVersion = 1
+
+// kinds of objects a bot's remote can refer to
+const (
+	refBot      = "bot"
+	refAsteroid = "asteroid"
+	refDebris   = "debris"
+)
+
+type (
+	// savedGame is the save format of a game.
+	//
+	// Objects refer to each other by their index in the
+	// lists of the game.
+	savedGame struct {
+		Version int `json:"version"`
+
+		// Seed is the world seed. The game has no other
+		// random state.
+		Seed          int64      `json:"seed"`
+		Scenario      string     `json:"scenario"`
+		Tick          int64      `json:"tick"`
+		Step          int64      `json:"step"`
+		CyclesPerTick int        `json:"cycles_per_tick"`
+		Substeps      int        `json:"substeps"`
+		Metabolism    Metabolism `json:"metabolism"`
+		Camera        Camera     `json:"camera"`
+		World         World      `json:"world"`
+
+		// Hulls are saved by value, so a save does not
+		// depend on the hulls file
+		Hulls     Hulls           `json:"hulls"`
+		Bots      []savedBot      `json:"bots"`
+		Asteroids []savedAsteroid `json:"asteroids"`
+		Debris    []savedDebris   `json:"debris"`
+		Docks     []savedDock     `json:"docks"`
+		Tethers   []savedTether   `json:"tethers"`
+		Wells     []GravityWell   `json:"wells"`
+	}
+
+	savedBody struct {
+		Position        cp.Vector `json:"position"`
+		Velocity        cp.Vector `json:"velocity"`
+		Angle           float64   `json:"angle"`
+		AngularVelocity float64   `json:"angular_velocity"`
+		Mass            float64   `json:"mass"`
+	}
+
+	savedBot struct {
+		ID        int16     `json:"id"`
+		Hull      string    `json:"hull"`
+		Body      savedBody `json:"body"`
+		Impact    float64   `json:"impact"`
+		Destroyed bool      `json:"destroyed"`
+		Remote    *savedRef `json:"remote,omitempty"`
+
+		// Program is the source of the bot's program
+		Program   string       `json:"program"`
+		Registers []int16      `json:"registers"`
+		Stack     []int16      `json:"stack"`
+		Activated map[int]bool `json:"activated"`
+	}
+
+	// savedRef refers to a shape of an object
+	savedRef struct {
+		Kind  string `json:"kind"`
+		Index int    `json:"index"`
+		// Shape is the index of the shape of an asteroid
+		Shape int `json:"shape,omitempty"`
+	}
+
+	savedAsteroid struct {
+		Bounds      image.Rectangle `json:"bounds"`
+		Outline     []cp.Vector     `json:"outline"`
+		Composition Composition     `json:"composition"`
+		Density     float64         `json:"density"`
+		Body        savedBody       `json:"body"`
+	}
+
+	savedDebris struct {
+		Radius float64   `json:"radius"`
+		Body   savedBody `json:"body"`
+	}
+
+	savedDock struct {
+		A       int       `json:"a"`
+		B       int       `json:"b"`
+		AnchorA cp.Vector `json:"anchor_a"`
+		AnchorB cp.Vector `json:"anchor_b"`
+		Phase   float64   `json:"phase"`
+	}
+
+	savedTether struct {
+		Bot      int       `json:"bot"`
+		Asteroid int       `json:"asteroid"`
+		Anchor   cp.Vector `json:"anchor"`
+		Length   float64   `json:"length"`
+	}
+)
+
+// Save writes the world to w. Saves are taken between
+// ticks.
+//
+// The contact caches of the physics engine are not saved,
+// so g and a game loaded from the save drift apart once
+// objects touch. Call Resync before saving for a save
+// with the same future as g.
+func (g *Game) Save(w io.Writer) error {
+	return errors.Wrap(json.NewEncoder(w).Encode(g.save()), "error encoding game")
+}
+
+// Resync rebuilds the physics space of g the way loading
+// a save of g builds it.
+//
+// Contact caches, joint impulses and the order of objects
+// in the physics engine are dropped, everything else is
+// saved exactly. After Resync, g continues like every game
+// loaded from a save of it, loading resyncs as well. The
+// objects of the game are kept, only docks and tethers are
+// joined again.
+func (g *Game) Resync() {
+	old := g.space
+	docks, tethers := g.docks, g.tethers
+	for _, d := range docks {
+		old.RemoveConstraint(d.pivot)
+		old.RemoveConstraint(d.gear)
+	}
+	for _, t := range tethers {
+		old.RemoveConstraint(t.joint)
+	}
+	g.docks, g.tethers = nil, nil
+
+	// objects are added in the order they are saved
+	g.space = cp.NewSpace()
+	g.walls = nil
+	g.setWorld(g.world)
+	g.initCollisions()
+	for _, b := range g.bots {
+		b.space = g.space
+		g.move(old, b.Body, b.Shape)
+	}
+	for _, a := range g.asteroids {
+		a.space = g.space
+		// bodies list their shapes last added first
+		var shapes []*cp.Shape
+		a.Body.EachShape(func(s *cp.Shape) {
+			shapes = append([]*cp.Shape{s}, shapes...)
+		})
+		g.move(old, a.Body, shapes...)
+	}
+	for _, d := range g.debris {
+		d.space = g.space
+		g.move(old, d.Body, d.Shape)
+	}
+	for _, w := range g.wells {
+		g.move(old, w.body, w.shape)
+		w.move(float64(g.tick) * timeStep)
+	}
+	for _, d := range docks {
+		pivot := d.pivot.Class.(*cp.PivotJoint)
+		g.join(d.a, d.b, pivot.AnchorA, pivot.AnchorB, d.phase)
+	}
+	for _, t := range tethers {
+		g.tie(t.bot, t.asteroid, t.anchor, t.Length())
+	}
+}
+
+// move moves body and its shapes from the space old to
+// the space of g
+func (g *Game) move(old *cp.Space, body *cp.Body, shapes ...*cp.Shape) {
+	for _, s := range shapes {
+		old.RemoveShape(s)
+	}
+	old.RemoveBody(body)
+	// drop the bias the contacts left for the next step,
+	// a zero step keeps the position
+	cp.BodyUpdatePosition(body, 0)
+	g.space.AddBody(body)
+	for _, s := range shapes {
+		g.space.AddShape(s)
+	}
+}
+
+func (g *Game) save() savedGame {
+	s := savedGame{
+		Version:       saveVersion,
+		Seed:          g.seed,
+		Scenario:      g.scenario,
+		Tick:          g.tick,
+		Step:          g.step,
+		CyclesPerTick: g.cyclesPerTick,
+		Substeps:      g.substeps,
+		Metabolism:    g.metabolism,
+		Camera:        g.camera,
+		World:         g.world,
+		Hulls:         make(Hulls),
+	}
+
+	bots := make(map[*Bot]int, len(g.bots))
+	asteroids := make(map[*Asteroid]int, len(g.asteroids))
+	refs := make(map[*cp.Shape]*savedRef)
+	for i, b := range g.bots {
+		bots[b] = i
+		refs[b.Shape] = &savedRef{Kind: refBot, Index: i}
+	}
+	for i, a := range g.asteroids {
+		asteroids[a] = i
+		var n int
+		a.Body.EachShape(func(sh *cp.Shape) {
+			refs[sh] = &savedRef{Kind: refAsteroid, Index: i, Shape: n}
+			n++
+		})
+	}
+	for i, d := range g.debris {
+		refs[d.Shape] = &savedRef{Kind: refDebris, Index: i}
+	}
+
+	for _, b := range g.bots {
+		s.Hulls[b.hull.Name] = b.hull
+		m := b.machine
+		s.Bots = append(s.Bots, savedBot{
+			ID:        b.id,
+			Hull:      b.hull.Name,
+			Body:      saveBody(b.Body),
+			Impact:    b.impact,
+			Destroyed: b.destroyed,
+			Remote:    refs[b.remote],
+			Program:   m.program.String(),
+			Registers: m.registers,
+			Stack:     *m.stack,
+			Activated: m.activated,
+		})
+	}
+	for _, a := range g.asteroids {
+		s.Asteroids = append(s.Asteroids, savedAsteroid{
+			Bounds:      a.Bounds,
+			Outline:     a.Outline,
+			Composition: a.Composition,
+			Density:     a.density,
+			Body:        saveBody(a.Body),
+		})
+	}
+	for _, d := range g.debris {
+		s.Debris = append(s.Debris, savedDebris{
+			Radius: d.Radius(),
+			Body:   saveBody(d.Body),
+		})
+	}
+	for _, d := range g.docks {
+		pivot := d.pivot.Class.(*cp.PivotJoint)
+		s.Docks = append(s.Docks, savedDock{
+			A:       bots[d.a],
+			B:       bots[d.b],
+			AnchorA: pivot.AnchorA,
+			AnchorB: pivot.AnchorB,
+			Phase:   d.phase,
+		})
+	}
+	for _, w := range g.wells {
+		s.Wells = append(s.Wells, *w)
+	}
+	for _, t := range g.tethers {
+		s.Tethers = append(s.Tethers, savedTether{
+			Bot:      bots[t.bot],
+			Asteroid: asteroids[t.asteroid],
+			Anchor:   t.anchor,
+			Length:   t.Length(),
+		})
+	}
+	return s
+}
+
+// LoadGame creates a game from the save read from r
+func LoadGame(r io.Reader) (*Game, error) {
+	g := &Game{}
+	if err := g.Restore(r); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Restore initializes g and loads the save read from r
+// into it. The current world of g is replaced.
+//
+// The save is validated first, g is not changed if it is
+// invalid.
+func (g *Game) Restore(r io.Reader) error {
+	s, programs, err := decodeGame(r)
+	if err != nil {
+		return err
+	}
+	g.restore(s, programs)
+	g.emit(Event{Type: EventRestore})
+	return nil
+}
+
+// decodeGame reads and validates a save. It returns the
+// parsed programs of the bots.
+func decodeGame(r io.Reader) (*savedGame, []Program, error) {
+	var s savedGame
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, nil, errors.Wrap(err, "error decoding game")
+	}
+	if s.Version != saveVersion {
+		return nil, nil, errors.Errorf("unsupported save version %d", s.Version)
+	}
+	if err := s.World.validate(); err != nil {
+		return nil, nil, err
+	}
+	if s.Hulls == nil {
+		s.Hulls = make(Hulls)
+	}
+	if err := s.Hulls.init(); err != nil {
+		return nil, nil, err
+	}
+
+	programs := make([]Program, len(s.Bots))
+	for i, sb := range s.Bots {
+		hull, ok := s.Hulls[sb.Hull]
+		if !ok {
+			return nil, nil, errors.Errorf("bot %d: unknown hull %q", i, sb.Hull)
+		}
+		program, err := NewParser(strings.NewReader(sb.Program)).Parse()
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "bot %d: error parsing program", i)
+		}
+		programs[i] = program
+		if len(sb.Registers) != hull.Memory {
+			return nil, nil, errors.Errorf("bot %d: %d registers, hull has %d", i, len(sb.Registers), hull.Memory)
+		}
+		if !validMass(sb.Body.Mass) {
+			return nil, nil, errors.Errorf("bot %d: invalid mass %v", i, sb.Body.Mass)
+		}
+		if sb.Remote != nil {
+			if err := s.checkRef(*sb.Remote); err != nil {
+				return nil, nil, errors.Wrapf(err, "bot %d: invalid remote", i)
+			}
+		}
+	}
+	for i, sa := range s.Asteroids {
+		if !validMass(sa.Density) || len(decompose(sa.Outline)) == 0 {
+			return nil, nil, errors.Errorf("asteroid %d: invalid outline or density", i)
+		}
+	}
+	for i, sd := range s.Debris {
+		if !validMass(sd.Body.Mass) || !(sd.Radius > 0) {
+			return nil, nil, errors.Errorf("debris %d: invalid mass or radius", i)
+		}
+	}
+	for i, w := range s.Wells {
+		if err := w.validate(); err != nil {
+			return nil, nil, errors.Wrapf(err, "well %d", i)
+		}
+	}
+	for i, sd := range s.Docks {
+		if !s.validBot(sd.A) || !s.validBot(sd.B) {
+			return nil, nil, errors.Errorf("dock %d: invalid bot", i)
+		}
+		if sd.A == sd.B {
+			return nil, nil, errors.Errorf("dock %d: bot %d docks to itself", i, sd.A)
+		}
+	}
+	for i, st := range s.Tethers {
+		if !s.validBot(st.Bot) || st.Asteroid < 0 || st.Asteroid >= len(s.Asteroids) {
+			return nil, nil, errors.Errorf("tether %d: invalid object", i)
+		}
+	}
+	return &s, programs, nil
+}
+
+// restore replaces the world of g by the validated save s
+func (g *Game) restore(s *savedGame, programs []Program) {
+	g.seed = s.Seed
+	g.scenario = s.Scenario
+	g.cyclesPerTick = s.CyclesPerTick
+	g.substeps = s.Substeps
+	g.init()
+	g.tick, g.step = s.Tick, s.Step
+	g.metabolism = s.Metabolism
+	g.camera = s.Camera
+	g.hulls = s.Hulls
+	g.setWorld(s.World)
+
+	for i, sb := range s.Bots {
+		b := NewBot(g.space, sb.ID, g.hulls[sb.Hull])
+		sb.Body.restore(b.Body)
+		b.setMass(sb.Body.Mass)
+		b.impact = sb.Impact
+		b.destroyed = sb.Destroyed
+		b.machine.program = programs[i]
+		copy(b.machine.registers, sb.Registers)
+		*b.machine.stack = append((*b.machine.stack)[:0], sb.Stack...)
+		for k, v := range sb.Activated {
+			b.machine.activated[k] = v
+		}
+		g.bots = append(g.bots, b)
+	}
+	for _, sa := range s.Asteroids {
+		a := NewAsteroid(g.space, sa.Bounds)
+		a.Outline = sa.Outline
+		a.Composition = sa.Composition
+		a.density = sa.Density
+		sa.Body.restore(a.Body)
+		a.addShapes()
+		g.asteroids = append(g.asteroids, a)
+	}
+	for _, sd := range s.Debris {
+		d := newDebris(g.space, sd.Body.Mass, sd.Radius, sd.Body.Position, sd.Body.Velocity)
+		sd.Body.restore(d.Body)
+		g.debris = append(g.debris, d)
+	}
+
+	for _, w := range s.Wells {
+		g.AddWell(w)
+	}
+
+	for i, sb := range s.Bots {
+		if sb.Remote != nil {
+			g.bots[i].remote = g.resolve(*sb.Remote)
+		}
+	}
+	for _, sd := range s.Docks {
+		g.join(g.bots[sd.A], g.bots[sd.B], sd.AnchorA, sd.AnchorB, sd.Phase)
+	}
+	for _, st := range s.Tethers {
+		g.tie(g.bots[st.Bot], g.asteroids[st.Asteroid], st.Anchor, st.Length)
+	}
+	// the objects were indexed before they were placed,
+	// index them where they are like a resynced game
+	g.Resync()
+}
+
+// saveFile saves the world to the file path
+func (g *Game) saveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "error creating save file")
+	}
+	if err := g.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return errors.Wrap(f.Close(), "error writing save file")
+}
+
+// restoreFile loads the save from the file path into g
+func (g *Game) restoreFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "error opening save file")
+	}
+	defer f.Close()
+	return g.Restore(f)
+}
+
+func (s *savedGame) validBot(i int) bool {
+	return i >= 0 && i < len(s.Bots)
+}
+
+// checkRef returns an error if ref does not refer to a
+// shape of the save
+func (s *savedGame) checkRef(ref savedRef) error {
+	switch ref.Kind {
+	case refBot:
+		if s.validBot(ref.Index) {
+			return nil
+		}
+	case refDebris:
+		if ref.Index >= 0 && ref.Index < len(s.Debris) {
+			return nil
+		}
+	case refAsteroid:
+		if ref.Index < 0 || ref.Index >= len(s.Asteroids) {
+			break
+		}
+		// the shapes of an asteroid are its convex parts
+		if ref.Shape >= 0 && ref.Shape < len(decompose(s.Asteroids[ref.Index].Outline)) {
+			return nil
+		}
+	default:
+		return errors.Errorf("unknown kind %q", ref.Kind)
+	}
+	return errors.Errorf("no %s %d", ref.Kind, ref.Index)
+}
+
+// resolve returns the shape the valid ref refers to
+func (g *Game) resolve(ref savedRef) *cp.Shape {
+	switch ref.Kind {
+	case refBot:
+		return g.bots[ref.Index].Shape
+	case refDebris:
+		return g.debris[ref.Index].Shape
+	}
+	var shape *cp.Shape
+	var n int
+	g.asteroids[ref.Index].Body.EachShape(func(s *cp.Shape) {
+		if n == ref.Shape {
+			shape = s
+		}
+		n++
+	})
+	return shape
+}
+
+// validMass returns true if m is a positive, finite mass
+// or density
+func validMass(m float64) bool {
+	return m > 0 && !math.IsInf(m, 1)
+}
+
+func saveBody(b *cp.Body) savedBody {
+	return savedBody{
+		Position:        b.Position(),
+		Velocity:        b.Velocity(),
+		Angle:           b.Angle(),
+		AngularVelocity: b.AngularVelocity(),
+		Mass:            b.Mass(),
+	}
+}
+
+// restore sets the state of b. The mass is set by the
+// owner of b, its moment of inertia depends on the shape.
+func (s savedBody) restore(b *cp.Body) {
+	b.SetPosition(s.Position)
+	b.SetVelocityVector(s.Velocity)
+	b.SetAngle(s.Angle)
+	b.SetAngularVelocity(s.AngularVelocity)
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveLoad(t *testing.T) {
+	for _, scenario := range []string{"all", "asteroid", "field", "belt"} {
+		t.Run(scenario, func(t *testing.T) {
+			g, err := NewGame(scenario, 42)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer g.Close()
+			for i := 0; i < 100; i++ {
+				g.Tick()
+			}
+
+			var save bytes.Buffer
+			if !assert.NoError(t, g.Save(&save)) {
+				return
+			}
+
+			loaded, err := LoadGame(bytes.NewReader(save.Bytes()))
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer loaded.Close()
+			assert.Equal(t, g.Hash(), loaded.Hash())
+			assert.Equal(t, g.world, loaded.world)
+			assert.Len(t, loaded.walls, len(g.walls))
+			assert.Len(t, loaded.wells, len(g.wells))
+
+			// saving the loaded game gives the same save
+			var resave bytes.Buffer
+			if !assert.NoError(t, loaded.Save(&resave)) {
+				return
+			}
+			assert.Equal(t, save.String(), resave.String())
+
+			// a save always has the same future
+			other, err := LoadGame(bytes.NewReader(save.Bytes()))
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer other.Close()
+			for i := 0; i < 200; i++ {
+				loaded.Tick()
+				other.Tick()
+				if !assert.Equal(t, loaded.Hash(), other.Hash(), "tick %d", loaded.tick) {
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestSaveLoadContinuesRun(t *testing.T) {
+	for _, scenario := range []string{"field", "belt"} {
+		t.Run(scenario, func(t *testing.T) {
+			g, err := NewGame(scenario, 42)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer g.Close()
+			for i := 0; i < 500; i++ {
+				g.Tick()
+			}
+
+			bots := append([]*Bot(nil), g.bots...)
+			asteroids := append([]*Asteroid(nil), g.asteroids...)
+			g.Resync()
+			// resyncing keeps the objects
+			for i, b := range bots {
+				assert.Same(t, b, g.bots[i])
+			}
+			for i, a := range asteroids {
+				assert.Same(t, a, g.asteroids[i])
+			}
+			var save bytes.Buffer
+			if !assert.NoError(t, g.Save(&save)) {
+				return
+			}
+			loaded, err := LoadGame(&save)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer loaded.Close()
+
+			var contacts int
+			for i := 0; i < 1000; i++ {
+				g.Tick()
+				loaded.Tick()
+				if !assert.Equal(t, g.Hash(), loaded.Hash(), "tick %d", g.tick) {
+					return
+				}
+				contacts += testContacts(g)
+			}
+			// the games continued the same while objects
+			// touched
+			assert.Greater(t, contacts, 0)
+		})
+	}
+}
+
+// testContacts returns the number of contacts of asteroids
+func testContacts(g *Game) int {
+	var n int
+	for _, a := range g.asteroids {
+		a.Body.EachArbiter(func(*cp.Arbiter) {
+			n++
+		})
+	}
+	return n
+}
+
+func TestSaveLoadConnections(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	hull := testHull(t)
+	g.hulls = Hulls{"test": hull}
+	hull.Name = "test"
+
+	a := NewAsteroid(g.space, image.Rect(0, 0, 100, 100))
+	a.generate(1)
+	g.asteroids = append(g.asteroids, a)
+
+	b1 := NewBot(g.space, 1, hull)
+	b1.SetPosition(cp.Vector{X: -100, Y: 0})
+	b2 := NewBot(g.space, 2, hull)
+	b2.SetPosition(cp.Vector{X: -100 - 2*hull.Radius, Y: 0})
+	g.bots = append(g.bots, b1, b2)
+	g.dock(b1, b2)
+	g.attach(b1, a, a.LocalToWorld(a.Outline[0]))
+	b1.machine.registers[0] = 7
+	a.Body.EachShape(func(s *cp.Shape) {
+		b2.remote = s
+	})
+	g.camera = Camera{Target: cp.Vector{X: 1, Y: 2}, Zoom: 3}
+
+	var save bytes.Buffer
+	if !assert.NoError(t, g.Save(&save)) {
+		return
+	}
+	loaded, err := LoadGame(&save)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer loaded.Close()
+
+	if !assert.Len(t, loaded.bots, 2) {
+		return
+	}
+	if !assert.Len(t, loaded.asteroids, 1) {
+		return
+	}
+	assert.Len(t, loaded.docks, 1)
+	if assert.Len(t, loaded.tethers, 1) {
+		tether := loaded.tethers[0]
+		assert.Equal(t, loaded.bots[0], tether.bot)
+		assert.Equal(t, loaded.bots[0].tether, tether)
+		assert.Equal(t, loaded.asteroids[0], tether.asteroid)
+		assert.Equal(t, g.tethers[0].Length(), tether.Length())
+	}
+	if assert.NotNil(t, loaded.bots[1].remote) {
+		assert.Equal(t, loaded.asteroids[0], loaded.bots[1].remote.UserData)
+	}
+	assert.Equal(t, int16(7), loaded.bots[0].machine.registers[0])
+	assert.Equal(t, g.camera, loaded.camera)
+	assert.Equal(t, g.Hash(), loaded.Hash())
+}
+
+func TestLoadInvalidSave(t *testing.T) {
+	for name, save := range map[string]string{
+		"garbage":   "moonshot",
+		"version":   `{"version": 0}`,
+		"hull":      `{"version": 1, "bots": [{"hull": "unknown"}]}`,
+		"dock":      `{"version": 1, "docks": [{"a": 0, "b": 1}]}`,
+		"remote":    `{"version": 1, "bots": [{"hull": "standard", "registers": [0], "body": {"mass": 100}, "remote": {"kind": "debris", "index": 0}}], "hulls": {"standard": {"mass": 100, "radius": 8, "memory": 1, "reactor": {"efficiency": 1}, "thrusters": {"curve": [{}]}}}}`,
+		"world":     `{"version": 1, "world": {"kind": 2}}`,
+		"asteroid":  `{"version": 1, "asteroids": [{"density": 1, "outline": [{"x": 0, "y": 0}]}]}`,
+		"null hull": `{"version": 1, "hulls": {"standard": null}}`,
+		"well":      `{"version": 1, "wells": [{"mu": 1000, "radius": 0}]}`,
+		"well mu":   `{"version": 1, "wells": [{"mu": -1000, "radius": 50}]}`,
+		"self dock": `{"version": 1, "bots": [{"hull": "standard", "registers": [0], "body": {"mass": 100}}], "docks": [{"a": 0, "b": 0}], "hulls": {"standard": {"mass": 100, "radius": 8, "memory": 1, "reactor": {"efficiency": 1}, "thrusters": {"curve": [{}]}}}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadGame(bytes.NewReader([]byte(save)))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRestoreInvalidSave(t *testing.T) {
+	g, err := NewGame("all", 42)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer g.Close()
+	var save bytes.Buffer
+	if !assert.NoError(t, g.Save(&save)) {
+		return
+	}
+	hash := g.Hash()
+
+	// a dock with a bot that does not exist
+	invalid := bytes.Replace(save.Bytes(), []byte(`"docks":null`),
+		[]byte(`"docks":[{"a":0,"b":1000}]`), 1)
+	if !assert.NotEqual(t, save.Bytes(), invalid) {
+		return
+	}
+	if !assert.Error(t, g.Restore(bytes.NewReader(invalid))) {
+		return
+	}
+	// the game is unchanged and still runs
+	assert.Equal(t, hash, g.Hash())
+	assert.NoError(t, g.Tick())
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"math"
+	"sync"
+
+	"github.com/jakecoffman/cp"
+)
+
+const (
+	// scanCellSize is the size of the cells of the scan
+	// index, in the order of the scanners' range
+	scanCellSize = 256
+	// scanMaxCells is the number of cells above which a
+	// shape is not put into cells but checked on every query
+	scanMaxCells = 64
+)
+
+type (
+	// scanIndex is a read-only spatial index of everything
+	// bots can scan.
+	//
+	// Queries on the space are not safe for concurrent use,
+	// so the machines scan the index instead. It is built on
+	// the first query of a cycle and must not be used after
+	// the world changed.
+	scanIndex struct {
+		g    *Game
+		once sync.Once
+
+		// shapes are all shapes, cells the shapes by cell
+		shapes []*cp.Shape
+		cells  map[scanCell][]*cp.Shape
+		// large are the shapes spanning too many cells
+		large []*cp.Shape
+	}
+
+	scanCell struct {
+		X, Y int
+	}
+)
+
+func (g *Game) newScanIndex() *scanIndex {
+	return &scanIndex{g: g}
+}
+
+// build adds the shapes of all objects in the order of the
+// game, so queries are deterministic
+func (idx *scanIndex) build() {
+	g := idx.g
+	idx.cells = make(map[scanCell][]*cp.Shape)
+	for _, b := range g.bots {
+		idx.add(b.Shape)
+	}
+	for _, a := range g.asteroids {
+		a.Body.EachShape(idx.add)
+	}
+	for _, d := range g.debris {
+		idx.add(d.Shape)
+	}
+	for _, w := range g.wells {
+		idx.add(w.shape)
+	}
+	for _, s := range g.walls {
+		idx.add(s)
+	}
+}
+
+func (idx *scanIndex) add(s *cp.Shape) {
+	idx.shapes = append(idx.shapes, s)
+	min, max, n := cellsOf(s.BB())
+	if !(n <= scanMaxCells) {
+		idx.large = append(idx.large, s)
+		return
+	}
+	for x := min.X; x <= max.X; x++ {
+		for y := min.Y; y <= max.Y; y++ {
+			c := scanCell{X: x, Y: y}
+			idx.cells[c] = append(idx.cells[c], s)
+		}
+	}
+}
+
+// query calls f for each shape whose bounding box
+// intersects bb, once per shape
+func (idx *scanIndex) query(bb cp.BB, f func(*cp.Shape)) {
+	idx.once.Do(idx.build)
+	min, max, n := cellsOf(bb)
+	if !(n <= float64(len(idx.shapes))) {
+		// checking all shapes is faster than checking
+		// all cells
+		for _, s := range idx.shapes {
+			if s.BB().Intersects(bb) {
+				f(s)
+			}
+		}
+		return
+	}
+	for _, s := range idx.large {
+		if s.BB().Intersects(bb) {
+			f(s)
+		}
+	}
+	for x := min.X; x <= max.X; x++ {
+		for y := min.Y; y <= max.Y; y++ {
+			c := scanCell{X: x, Y: y}
+			for _, s := range idx.cells[c] {
+				sb := s.BB()
+				if !sb.Intersects(bb) {
+					continue
+				}
+				// shapes in several cells are reported in
+				// the cell of the corner of the overlap
+				if cellOf(math.Max(sb.L, bb.L), math.Max(sb.B, bb.B)) != c {
+					continue
+				}
+				f(s)
+			}
+		}
+	}
+}
+
+// cellsOf returns the first and last cell bb overlaps and
+// the number of cells. The cells are only valid if the
+// number is not too large.
+func cellsOf(bb cp.BB) (min, max scanCell, n float64) {
+	l, b := math.Floor(bb.L/scanCellSize), math.Floor(bb.B/scanCellSize)
+	r, t := math.Floor(bb.R/scanCellSize), math.Floor(bb.T/scanCellSize)
+	n = (r - l + 1) * (t - b + 1)
+	if !(n <= scanMaxCells*scanMaxCells) {
+		return min, max, math.Inf(1)
+	}
+	return cellOf(bb.L, bb.B), cellOf(bb.R, bb.T), n
+}
+
+func cellOf(x, y float64) scanCell {
+	return scanCell{
+		X: int(math.Floor(x / scanCellSize)),
+		Y: int(math.Floor(y / scanCellSize)),
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanIndex(t *testing.T) {
+	g, err := NewGame("all", 1)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer g.Close()
+	g.SetWorld(World{Kind: WorldWalled, Bounds: cp.BB{L: -5000, B: -5000, R: 5000, T: 5000}})
+	g.space.Step(timeStep)
+
+	scan := g.newScanIndex()
+	rnd := rand.New(rand.NewSource(1))
+	bbs := []cp.BB{{L: -1e9, B: -1e9, R: 1e9, T: 1e9}}
+	for i := 0; i < 100; i++ {
+		p := cp.Vector{X: rnd.Float64()*6000 - 3000, Y: rnd.Float64()*6000 - 3000}
+		bbs = append(bbs, cp.NewBBForCircle(p, rnd.Float64()*1000))
+	}
+	for _, bb := range bbs {
+		var want, got []*cp.Shape
+		g.space.BBQuery(bb, cp.SHAPE_FILTER_ALL, func(s *cp.Shape, _ interface{}) {
+			want = append(want, s)
+		}, nil)
+		scan.query(bb, func(s *cp.Shape) {
+			got = append(got, s)
+		})
+		if !assert.ElementsMatch(t, want, got, "%v", bb) {
+			return
+		}
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"image"
 	"strings"
-	"time"
 
 	"github.com/jakecoffman/cp"
 )
@@ -22,13 +21,20 @@ func (f ScenarioFunc) LoadScenario(g *Game) {
 
 var scenarios = map[string]ScenarioFunc{
 	"all": func(g *Game) {
+		err := g.SetWorld(World{
+			Kind:   WorldWalled,
+			Bounds: cp.BB{L: -1000, B: -1000, R: 1000, T: 1000},
+		})
+		if err != nil {
+			panic(err)
+		}
 
-		b := NewBot(g.space, 1)
+		b := NewBot(g.space, 1, g.hulls["standard"])
 		b.SetPosition(cp.Vector{X: 0, Y: 100})
 		b.SetVelocity(100, 0)
 		g.bots = append(g.bots, b)
 
-		b = NewBot(g.space, 1)
+		b = NewBot(g.space, 1, g.hulls["standard"])
 		b.SetPosition(cp.Vector{X: 600, Y: 100})
 		b.SetVelocity(-10, 0)
 		g.bots = append(g.bots, b)
@@ -134,22 +140,105 @@ END
 			panic(err)
 		}
 
-		b = NewBot(g.space, 1)
+		b = NewBot(g.space, 1, g.hulls["standard"])
 		b.SetPosition(cp.Vector{X: 200, Y: 200})
 		b.machine.program = program
 		g.bots = append(g.bots, b)
 	},
 
 	"asteroid": func(g *Game) {
+		g.metabolism = Metabolism{}
+
 		a := NewAsteroid(g.space, image.Rect(0, 0, 500, 500))
-		a.generate(time.Now().Unix())
+		a.Generator = NoisyCircleGenerator{
+			Vertices: 48,
+			Noise:    .25,
+			Concave:  true,
+		}
+		a.generate(g.seed)
 		a.SetVelocity(80, 80)
 		g.asteroids = append(g.asteroids, a)
 
 		a2 := NewAsteroid(g.space, image.Rect(0, 0, 600, 600))
-		a2.SetPosition(cp.Vector{2000, 2000})
-		a2.generate(time.Now().Unix())
+		a2.Generator = RandomWalkGenerator{
+			MinLength: 40,
+			MaxLength: 160,
+			MaxTurn:   1,
+			MaxEdge:   80,
+			Concave:   true,
+		}
+		a2.SetPosition(cp.Vector{X: 2000, Y: 2000})
+		a2.generate(g.seed + 1)
 		a2.SetVelocity(-150, -150)
 		g.asteroids = append(g.asteroids, a2)
 	},
+
+	"field": func(g *Game) {
+		g.metabolism = Metabolism{}
+
+		AsteroidField{
+			Region:     RectRegion{cp.BB{L: -5000, B: -5000, R: 5000, T: 5000}},
+			Count:      200,
+			Sizes:      PowerLaw{Min: 40, Max: 600, Alpha: 2},
+			MinSpacing: 50,
+			Drift:      30,
+			Spin:       .2,
+		}.Generate(g, g.seed)
+	},
+
+	"torus": func(g *Game) {
+		g.metabolism = Metabolism{}
+
+		bounds := cp.BB{L: -2000, B: -2000, R: 2000, T: 2000}
+		if err := g.SetWorld(World{Kind: WorldToroidal, Bounds: bounds}); err != nil {
+			panic(err)
+		}
+		AsteroidField{
+			Region:     RectRegion{bounds},
+			Count:      60,
+			Sizes:      PowerLaw{Min: 40, Max: 300, Alpha: 2},
+			MinSpacing: 50,
+			Drift:      60,
+			Spin:       .2,
+		}.Generate(g, g.seed)
+	},
+
+	"belt": func(g *Game) {
+		g.metabolism = Metabolism{}
+
+		const sun = 2e8
+		g.AddWell(GravityWell{
+			Mu:     sun,
+			Radius: 800,
+			Sprite: sunSprite,
+		})
+		for _, p := range []struct {
+			orbit, radius, phase float64
+			sprite               int
+		}{
+			{orbit: 2500, radius: 150, phase: 0, sprite: 3},
+			{orbit: 7000, radius: 300, phase: 2, sprite: 12},
+		} {
+			g.AddWell(GravityWell{
+				Mu:     5e5,
+				Radius: p.radius,
+				Orbit: Orbit{
+					Radius: p.orbit,
+					Period: OrbitalPeriod(sun, p.orbit),
+					Phase:  p.phase,
+				},
+				Sprite: PlanetSprite(p.sprite),
+			})
+		}
+
+		AsteroidField{
+			Region:     RingRegion{Inner: 4000, Outer: 5000},
+			Count:      300,
+			Sizes:      PowerLaw{Min: 40, Max: 300, Alpha: 2},
+			MinSpacing: 20,
+			Drift:      5,
+			Orbit:      sun,
+			Spin:       .2,
+		}.Generate(g, g.seed)
+	},
 }
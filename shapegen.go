@@ -0,0 +1,264 @@
+package main
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/jakecoffman/cp"
+)
+
+type (
+	// ShapeGenerator generates the outline of an asteroid.
+	//
+	// Implementations must be deterministic: the same seed and
+	// bounds always result in the same outline. Outlines are
+	// simple polygons, counter-clockwise and fit into bounds.
+	ShapeGenerator interface {
+		Generate(seed int64, bounds image.Rectangle) []cp.Vector
+	}
+
+	// ValtrGenerator generates random convex polygons
+	// following Valtr's algorithm.
+	//
+	// see https://cglab.ca/~sander/misc/ConvexGeneration/convex.html
+	ValtrGenerator struct {
+		// MinVerts (at least 3) and MaxVerts (at least
+		// MinVerts) limit the number of vertices
+		MinVerts, MaxVerts int
+	}
+
+	// RandomWalkGenerator walks a path of lines with random
+	// length and angle until it turned full circle, closes
+	// the loop and subdivides long edges.
+	//
+	// Fields that are not set fall back to
+	// defaultRandomWalk.
+	RandomWalkGenerator struct {
+		MinLength, MaxLength float64
+		// MaxTurn is the maximum turn between two lines
+		// in radians (minWalkTurn..π)
+		MaxTurn float64
+		// MaxEdge is the length above which edges are
+		// subdivided
+		MaxEdge float64
+		// Concave keeps the dents of the walk instead of
+		// reducing the outline to its convex hull
+		Concave bool
+	}
+
+	// NoisyCircleGenerator places vertices on a circle with
+	// randomly reduced radii.
+	NoisyCircleGenerator struct {
+		// Vertices is the number of vertices, at least 3
+		Vertices int
+		// Noise (0..1) is the maximum reduction of the radius
+		Noise float64
+		// Concave keeps the dents instead of reducing the
+		// outline to its convex hull
+		Concave bool
+	}
+
+	vects []cp.Vector
+)
+
+func (vs vects) Len() int           { return len(vs) }
+func (vs vects) Swap(i, j int)      { vs[i], vs[j] = vs[j], vs[i] }
+func (vs vects) Less(i, j int) bool { return vs[i].ToAngle() < vs[j].ToAngle() }
+
+// minWalkTurn is the smallest maximum turn of random
+// walks. Smaller turns take too many lines to turn full
+// circle.
+const minWalkTurn = math.Pi / 64
+
+// defaultShapeGenerator is used for asteroids without
+// generator
+var defaultShapeGenerator ShapeGenerator = ValtrGenerator{
+	MinVerts: 10,
+	MaxVerts: 64,
+}
+
+// defaultRandomWalk has the settings random walks fall
+// back to
+var defaultRandomWalk = RandomWalkGenerator{
+	MinLength: 20,
+	MaxLength: 80,
+	MaxTurn:   1,
+	MaxEdge:   40,
+}
+
+func (gen ValtrGenerator) Generate(seed int64, bounds image.Rectangle) []cp.Vector {
+	rnd := rand.New(rand.NewSource(seed))
+
+	if gen.MinVerts < 3 {
+		gen.MinVerts = 3
+	}
+	if gen.MaxVerts < gen.MinVerts {
+		gen.MaxVerts = gen.MinVerts
+	}
+
+	numVerts := rnd.Intn(gen.MaxVerts-gen.MinVerts+1) + gen.MinVerts
+
+	// generate 2 lists of random x and y coordinates
+	xs := make([]float64, numVerts)
+	ys := make([]float64, numVerts)
+	for i := 0; i < numVerts; i++ {
+		xs[i] = rnd.Float64() * float64(bounds.Dx())
+		ys[i] = rnd.Float64() * float64(bounds.Dy())
+	}
+	// sort them
+	sort.Float64s(xs)
+	sort.Float64s(ys)
+
+	x1 := make([]cp.Vector, 0, numVerts/2)
+	x2 := make([]cp.Vector, 0, numVerts/2)
+	y1 := make([]cp.Vector, 0, numVerts/2)
+	y2 := make([]cp.Vector, 0, numVerts/2)
+	// isolate the extreme points for x and y coordinates
+	// indexes 0 and len()-1
+	// randomly divde them into two chains
+	for i := 1; i < numVerts-1; i++ {
+		if rnd.Intn(2) == 0 {
+			x1 = append(x1, cp.Vector{X: xs[i]})
+			y1 = append(y1, cp.Vector{Y: ys[i]})
+		} else {
+			x2 = append(x2, cp.Vector{X: xs[i]})
+			y2 = append(y2, cp.Vector{Y: ys[i]})
+		}
+	}
+
+	// combine them into vectors
+	combiner := func(start, end cp.Vector, vectors []cp.Vector, neg bool) []cp.Vector {
+		for i, vec := range vectors {
+			tmp := vec
+			if neg {
+				vectors[i] = start.Sub(vec)
+			} else {
+				vectors[i] = vec.Sub(start)
+			}
+			start = tmp
+		}
+		if neg {
+			return append(vectors, start.Sub(end))
+		} else {
+			return append(vectors, end.Sub(start))
+		}
+	}
+	// combine mixed
+	x1 = append(combiner(cp.Vector{X: xs[0]}, cp.Vector{X: xs[numVerts-1]}, x1, false), combiner(cp.Vector{X: xs[0]}, cp.Vector{X: xs[numVerts-1]}, x2, true)...)
+	y1 = append(combiner(cp.Vector{Y: ys[0]}, cp.Vector{Y: ys[numVerts-1]}, y1, false), combiner(cp.Vector{Y: ys[0]}, cp.Vector{Y: ys[numVerts-1]}, y2, true)...)
+
+	// randomy pair up x and y
+	rnd.Shuffle(len(x1), func(i, j int) { x1[i], x1[j] = x1[j], x1[i] })
+	rnd.Shuffle(len(y1), func(i, j int) { y1[i], y1[j] = y1[j], y1[i] })
+
+	// sort by angle
+	vs := make([]cp.Vector, 0, numVerts)
+	for i, v := range x1 {
+		vs = append(vs, v.Add(y1[i]))
+	}
+	sort.Sort(vects(vs))
+
+	// lay the edge vectors end to end
+	verts := make([]cp.Vector, len(vs))
+	var vect cp.Vector
+	for i, v := range vs {
+		verts[i] = vect
+		vect = vect.Add(v)
+	}
+	return fitBounds(convexHull(verts), bounds)
+}
+
+func (gen RandomWalkGenerator) Generate(seed int64, bounds image.Rectangle) []cp.Vector {
+	rnd := rand.New(rand.NewSource(seed))
+
+	if gen.MaxLength <= 0 {
+		gen.MinLength, gen.MaxLength = defaultRandomWalk.MinLength, defaultRandomWalk.MaxLength
+	}
+	gen.MinLength = math.Max(0, math.Min(gen.MinLength, gen.MaxLength))
+	if gen.MaxTurn <= 0 {
+		gen.MaxTurn = defaultRandomWalk.MaxTurn
+	}
+	gen.MaxTurn = math.Max(minWalkTurn, math.Min(gen.MaxTurn, math.Pi))
+	if gen.MaxEdge <= 0 {
+		gen.MaxEdge = defaultRandomWalk.MaxEdge
+	}
+
+	verts := make([]cp.Vector, 0, 32)
+	var pos cp.Vector
+	var angle float64
+	for angle < 2*math.Pi {
+		verts = append(verts, pos)
+		length := gen.MinLength + rnd.Float64()*(gen.MaxLength-gen.MinLength)
+		pos = pos.Add(cp.ForAngle(angle).Mult(length))
+		angle += rnd.Float64() * gen.MaxTurn
+	}
+	// the loop is closed by the edge from the last
+	// vertex to the first
+
+	// subdivide long edges, bulging them outwards
+	out := make([]cp.Vector, 0, len(verts)*2)
+	for i, a := range verts {
+		b := verts[(i+1)%len(verts)]
+		out = append(out, a)
+		edge := b.Sub(a)
+		n := int(edge.Length() / gen.MaxEdge)
+		for j := 1; j <= n; j++ {
+			t := float64(j) / float64(n+1)
+			bulge := edge.ReversePerp().Normalize().Mult(rnd.Float64() * gen.MaxEdge / 4)
+			out = append(out, a.Lerp(b, t).Add(bulge))
+		}
+	}
+	// the walk only turns left, but closing the loop and
+	// bulging edges can make it cross itself
+	if !gen.Concave || !isSimple(out) {
+		out = convexHull(out)
+	}
+	return fitBounds(out, bounds)
+}
+
+func (gen NoisyCircleGenerator) Generate(seed int64, bounds image.Rectangle) []cp.Vector {
+	rnd := rand.New(rand.NewSource(seed))
+
+	if gen.Vertices < 3 {
+		gen.Vertices = 3
+	}
+	gen.Noise = math.Max(0, math.Min(gen.Noise, 1))
+
+	verts := make([]cp.Vector, gen.Vertices)
+	for i := range verts {
+		angle := 2 * math.Pi * float64(i) / float64(gen.Vertices)
+		r := 1 - rnd.Float64()*gen.Noise
+		verts[i] = cp.ForAngle(angle).Mult(r)
+	}
+	if !gen.Concave {
+		verts = convexHull(verts)
+	}
+	return fitBounds(verts, bounds)
+}
+
+// convexHull reduces verts to their convex hull
+func convexHull(verts []cp.Vector) []cp.Vector {
+	return verts[:cp.ConvexHull(len(verts), verts, nil, 0)]
+}
+
+// fitBounds scales and moves verts to fit into bounds,
+// keeping their aspect ratio.
+func fitBounds(verts []cp.Vector, bounds image.Rectangle) []cp.Vector {
+	bb := cp.NewBBForCircle(verts[0], 0)
+	for _, v := range verts {
+		bb = bb.Expand(v)
+	}
+	w := float64(bounds.Dx()) - 2*asteroidBoundsPadding
+	h := float64(bounds.Dy()) - 2*asteroidBoundsPadding
+	scale := math.Min(w/(bb.R-bb.L), h/(bb.T-bb.B))
+	offset := cp.Vector{
+		X: float64(bounds.Min.X) + asteroidBoundsPadding,
+		Y: float64(bounds.Min.Y) + asteroidBoundsPadding,
+	}
+	for i, v := range verts {
+		verts[i] = v.Sub(cp.Vector{X: bb.L, Y: bb.B}).Mult(scale).Add(offset)
+	}
+	return verts
+}
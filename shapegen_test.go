@@ -0,0 +1,123 @@
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+	"github.com/stretchr/testify/assert"
+)
+
+var testShapeGenerators = map[string]ShapeGenerator{
+	"valtr": defaultShapeGenerator,
+	"random walk": RandomWalkGenerator{
+		MinLength: 20,
+		MaxLength: 80,
+		MaxTurn:   1,
+		MaxEdge:   40,
+	},
+	"noisy circle": NoisyCircleGenerator{
+		Vertices: 24,
+		Noise:    .3,
+	},
+}
+
+func TestShapeGenerators(t *testing.T) {
+	bounds := image.Rect(10, 20, 310, 220)
+	for name, gen := range testShapeGenerators {
+		t.Run(name, func(t *testing.T) {
+			for seed := int64(0); seed < 50; seed++ {
+				verts := gen.Generate(seed, bounds)
+				if !assert.GreaterOrEqual(t, len(verts), 3, "seed %d", seed) {
+					return
+				}
+				if !assertConvex(t, verts) {
+					t.Logf("seed %d", seed)
+					return
+				}
+				for _, v := range verts {
+					if !assert.True(t, inBounds(v, bounds), "seed %d: %v not in %v", seed, v, bounds) {
+						return
+					}
+				}
+				if !assert.Equal(t, verts, gen.Generate(seed, bounds), "seed %d", seed) {
+					return
+				}
+			}
+			assert.NotEqual(t, gen.Generate(1, bounds), gen.Generate(2, bounds))
+		})
+	}
+}
+
+func TestConcaveRandomWalk(t *testing.T) {
+	bounds := image.Rect(10, 20, 310, 220)
+	gen := testShapeGenerators["random walk"].(RandomWalkGenerator)
+	gen.Concave = true
+	var concave int
+	for seed := int64(0); seed < 50; seed++ {
+		verts := gen.Generate(seed, bounds)
+		if !assert.True(t, isSimple(verts), "seed %d", seed) {
+			return
+		}
+		for _, v := range verts {
+			if !assert.True(t, inBounds(v, bounds), "seed %d: %v not in %v", seed, v, bounds) {
+				return
+			}
+		}
+		if !assert.Equal(t, verts, gen.Generate(seed, bounds), "seed %d", seed) {
+			return
+		}
+		if len(convexHull(append([]cp.Vector(nil), verts...))) < len(verts) {
+			concave++
+		}
+	}
+	// the walk keeps its dents
+	assert.Greater(t, concave, 25)
+}
+
+func TestShapeGeneratorDefaults(t *testing.T) {
+	bounds := image.Rect(0, 0, 200, 200)
+	for name, gen := range map[string]ShapeGenerator{
+		"valtr":               ValtrGenerator{},
+		"valtr min above max": ValtrGenerator{MinVerts: 10, MaxVerts: 5},
+		"random walk":         RandomWalkGenerator{},
+		"random walk tiny turn": RandomWalkGenerator{
+			MinLength: 10,
+			MaxLength: 20,
+			MaxTurn:   1e-9,
+		},
+		"noisy circle":       NoisyCircleGenerator{},
+		"noisy circle noise": NoisyCircleGenerator{Vertices: 1, Noise: 3},
+	} {
+		t.Run(name, func(t *testing.T) {
+			verts := gen.Generate(1, bounds)
+			if !assert.GreaterOrEqual(t, len(verts), 3) {
+				return
+			}
+			for _, v := range verts {
+				if !assert.True(t, inBounds(v, bounds), "%v not in %v", v, bounds) {
+					return
+				}
+			}
+		})
+	}
+}
+
+// assertConvex asserts that verts are a convex,
+// counter-clockwise polygon
+func assertConvex(t *testing.T, verts []cp.Vector) bool {
+	t.Helper()
+	n := len(verts)
+	for i := range verts {
+		a, b, c := verts[i], verts[(i+1)%n], verts[(i+2)%n]
+		if !assert.GreaterOrEqual(t, b.Sub(a).Cross(c.Sub(b)), -1e-9, "convex at %d", i) {
+			return false
+		}
+	}
+	return true
+}
+
+func inBounds(v cp.Vector, bounds image.Rectangle) bool {
+	return v.X >= float64(bounds.Min.X) && v.X <= float64(bounds.Max.X) &&
+		v.Y >= float64(bounds.Min.Y) && v.Y <= float64(bounds.Max.Y)
+}
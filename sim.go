@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+)
+
+type (
+	// SimStats summarizes a simulation run
+	SimStats struct {
+		Scenario string
+		Seed     int64
+
+		Ticks, Steps int64
+		Duration     time.Duration
+
+		Bots, Asteroids, Debris int
+		// BotMass is the total mass of all living bots
+		BotMass float64
+
+		Events map[EventType]int
+		// Hash of the world state after the last tick
+		Hash uint64
+
+		Workers []WorkerStats
+	}
+)
+
+// NewGame creates a game from seed and loads scenario
+// into it
+func NewGame(scenario string, seed int64) (*Game, error) {
+	g := &Game{seed: seed}
+	if err := g.Load(scenario); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Load initializes g and loads scenario
+func (g *Game) Load(scenario string) error {
+	scen, ok := scenarios[scenario]
+	if !ok {
+		return errors.Errorf("unknown scenario %q", scenario)
+	}
+	g.init()
+
+	hulls, err := LoadHullsFile(hullsFile)
+	if err != nil {
+		return err
+	}
+	g.hulls = hulls
+
+	g.scenario = scenario
+	scen.LoadScenario(g)
+	return nil
+}
+
+// Simulate runs g for ticks without rendering.
+//
+// If the game is cancelled, the statistics up to the last
+// complete tick are returned with the error.
+func Simulate(g *Game, ticks int64) (SimStats, error) {
+	stats := SimStats{
+		Seed:   g.seed,
+		Events: make(map[EventType]int),
+	}
+	g.Subscribe(func(e Event) {
+		stats.Events[e.Type]++
+	})
+
+	start := time.Now()
+	startTick, startStep := g.tick, g.step
+	var err error
+	for i := int64(0); i < ticks && err == nil; i++ {
+		err = g.Tick()
+	}
+	stats.Duration = time.Since(start)
+	stats.Ticks, stats.Steps = g.tick-startTick, g.step-startStep
+
+	stats.Hash = g.Hash()
+	stats.Bots = len(g.bots)
+	stats.Asteroids = len(g.asteroids)
+	stats.Debris = len(g.debris)
+	for _, b := range g.bots {
+		stats.BotMass += b.Mass()
+	}
+	stats.Workers = g.pool.Stats()
+	return stats, err
+}
+
+// Log writes the statistics to l as key value pairs
+func (s SimStats) Log(l log.Logger) error {
+	keyvals := []interface{}{
+		"scenario", s.Scenario,
+		"seed", s.Seed,
+		"ticks", s.Ticks,
+		"steps", s.Steps,
+		"duration", s.Duration,
+		"ticks_per_second", float64(s.Ticks) / s.Duration.Seconds(),
+		"bots", s.Bots,
+		"asteroids", s.Asteroids,
+		"debris", s.Debris,
+		"bot_mass", s.BotMass,
+	}
+	for _, t := range []EventType{
+		EventBotDeath,
+		EventBotCollision,
+		EventTetherBreak,
+		EventAsteroidFracture,
+	} {
+		keyvals = append(keyvals, t.String(), s.Events[t])
+	}
+	keyvals = append(keyvals, "hash", fmt.Sprintf("%016x", s.Hash))
+	utilisation := make([]string, len(s.Workers))
+	for i, w := range s.Workers {
+		utilisation[i] = strconv.FormatFloat(w.Utilisation, 'f', 2, 64)
+	}
+	keyvals = append(keyvals, "utilisation", strings.Join(utilisation, ","))
+	return l.Log(keyvals...)
+}
+
+// runSim runs a simulation without window and writes the
+// statistics to w.
+//
+//	moonshot sim --scenario all --ticks 100000 --seed 42
+func runSim(args []string, w io.Writer) int {
+	fs := flag.NewFlagSet("sim", flag.ContinueOnError)
+	scenario := fs.String("scenario", "all", "scenario to simulate")
+	ticks := fs.Int64("ticks", 1000, "number of ticks to simulate")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "world seed")
+	workers := fs.Int("workers", 0, "number of workers, 0 for one per CPU")
+	load := fs.String("load", "", "resume the saved game instead of loading the scenario")
+	save := fs.String("save", "", "save the game to file when done")
+	record := fs.String("record", "", "record the run to file")
+	recordEvery := fs.Int("record-every", 1, "number of ticks between recorded frames")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	// stop on interrupt, the statistics so far are still written
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	g := &Game{
+		seed:       *seed,
+		ctx:        ctx,
+		numRunners: *workers,
+	}
+	var err error
+	if *load != "" {
+		err = g.restoreFile(*load)
+	} else {
+		err = g.Load(*scenario)
+	}
+	if err != nil {
+		errLog.Log("msg", "error creating game", "err", err)
+		return 1
+	}
+	defer g.Close()
+
+	var rec *Recorder
+	if *record != "" {
+		rec, err = RecordFile(g, *recordEvery, *record)
+		if err != nil {
+			errLog.Log("msg", "error recording game", "err", err)
+			return 1
+		}
+	}
+
+	stats, err := Simulate(g, *ticks)
+	if err != nil {
+		errLog.Log("msg", "simulation stopped", "err", err)
+	}
+	stats.Scenario = *scenario
+	if *load != "" {
+		stats.Scenario = *load
+	}
+	if rec != nil {
+		if err := rec.Close(); err != nil {
+			errLog.Log("msg", "error writing recording", "err", err)
+			return 1
+		}
+	}
+	if *save != "" {
+		if err := g.saveFile(*save); err != nil {
+			errLog.Log("msg", "error saving game", "err", err)
+			return 1
+		}
+	}
+	if err := stats.Log(log.NewLogfmtLogger(w)); err != nil {
+		errLog.Log("msg", "error writing statistics", "err", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulate(t *testing.T) {
+	for name := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			g, err := NewGame(name, 42)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer g.Close()
+			stats, err := Simulate(g, 60)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(60), stats.Ticks)
+			assert.Equal(t, int64(60*g.cyclesPerTick), stats.Steps)
+			assert.Equal(t, len(g.bots), stats.Bots)
+		})
+	}
+
+	_, err := NewGame("unknown", 42)
+	assert.Error(t, err)
+}
+
+func TestRunSim(t *testing.T) {
+	var buf bytes.Buffer
+	code := runSim([]string{"--scenario", "asteroid", "--ticks", "10", "--seed", "42"}, &buf)
+	if !assert.Equal(t, 0, code) {
+		return
+	}
+	assert.Contains(t, buf.String(), "scenario=asteroid seed=42 ticks=10 ")
+	assert.Contains(t, buf.String(), "asteroid_fracture=")
+
+	assert.NotEqual(t, 0, runSim([]string{"--ticks", "ten"}, &buf))
+}
+
+func TestRunSimSaveAndResume(t *testing.T) {
+	save := filepath.Join(t.TempDir(), "save.json")
+	args := []string{"--scenario", "asteroid", "--seed", "42"}
+
+	var straight, first, resumed bytes.Buffer
+	assert.Equal(t, 0, runSim(append(args, "--ticks", "30"), &straight))
+	assert.Equal(t, 0, runSim(append(args, "--ticks", "20", "--save", save), &first))
+	if !assert.Equal(t, 0, runSim([]string{"--load", save, "--ticks", "10"}, &resumed)) {
+		return
+	}
+
+	hash := func(out string) string {
+		for _, f := range strings.Fields(out) {
+			if strings.HasPrefix(f, "hash=") {
+				return f
+			}
+		}
+		return ""
+	}
+	assert.Equal(t, hash(straight.String()), hash(resumed.String()))
+	assert.NotEqual(t, 0, runSim([]string{"--load", filepath.Join(t.TempDir(), "missing.json")}, &resumed))
+}
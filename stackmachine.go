@@ -11,8 +11,8 @@ type (
 
 	// Machine is the stack machine powering bots.
 	//
-	// It is a 16 bit stack machine with 16 persistent
-	// registers.
+	// It is a 16 bit stack machine with a number of
+	// persistent registers determined by the bot's hull.
 	//
 	// state represents the interface to the bot.
 	Machine struct {
@@ -22,7 +22,7 @@ type (
 
 		program   Program
 		stack     *stack
-		registers [16]int16
+		registers []int16
 
 		state State
 
@@ -37,15 +37,16 @@ type (
 		// Reset resets the bot for each cycle
 		// Accumulated values (such as thrust vector) are reset
 		Reset()
-		// Execute takes accumulated values and activates
-		// the bot's systems
-		Execute()
 		// Returns current velocity vector X component
 		X() int16
 		// Returns current velocity vector Y component
 		Y() int16
 		// Returns current energy value, that is mass * Leonhard efficiency
 		Energy() int16
+		// Returns current heading in degrees
+		Heading() int16
+		// Returns the energy of collisions since the last cycle
+		Impact() int16
 		// Returns bot's ID
 		ID() int16
 		RemoteID(int16) int16
@@ -55,6 +56,12 @@ type (
 		Mine(int16)
 		Reproduce(int16)
 		Impulse(int16)
+		Give(int16)
+		Take(int16)
+		Dock()
+		Undock()
+		AttachTether()
+		ReleaseTether()
 	}
 )
 
@@ -93,10 +100,30 @@ func (p Program) String() string {
 	return b.String()
 }
 
-func NewMachine() *Machine {
+// Size returns the number of instructions of the program,
+// not counting comments and section statements
+func (p Program) Size() int {
+	var n int
+	for _, g := range p {
+		for _, code := range []AST{g.Evaluate, g.Execute} {
+			for _, inst := range code {
+				switch inst.(type) {
+				case *Comment, *Begin, *End:
+				default:
+					n++
+				}
+			}
+		}
+	}
+	return n
+}
+
+// NewMachine creates a machine with memory registers
+func NewMachine(memory int) *Machine {
 	m := &Machine{
-		run:   runInstruction,
-		stack: stackPool.Get().(*stack),
+		run:       runInstruction,
+		stack:     stackPool.Get().(*stack),
+		registers: make([]int16, memory),
 
 		activated: make(map[int]bool),
 	}
@@ -108,12 +135,14 @@ func (m *Machine) Destroy() {
 	m.stack = nil
 }
 
+// Run runs all genes of the program once. The machine only
+// reads and changes its state, the actions are applied by
+// the game afterwards.
 func (m *Machine) Run() {
 	m.state.Reset()
 	for i, g := range m.program {
 		m.activated[i] = m.RunGene(g)
 	}
-	m.state.Execute()
 }
 
 func (m *Machine) RunGene(g *Gene) bool {
@@ -12,10 +12,6 @@ func (s *StateMock) Reset() {
 	s.Called()
 }
 
-func (s *StateMock) Execute() {
-	s.Called()
-}
-
 func (s *StateMock) X() int16 {
 	args := s.Called()
 	return args.Get(0).(int16)
@@ -31,6 +27,16 @@ func (s *StateMock) Energy() int16 {
 	return args.Get(0).(int16)
 }
 
+func (s *StateMock) Heading() int16 {
+	args := s.Called()
+	return args.Get(0).(int16)
+}
+
+func (s *StateMock) Impact() int16 {
+	args := s.Called()
+	return args.Get(0).(int16)
+}
+
 func (s *StateMock) ID() int16 {
 	args := s.Called()
 	return args.Get(0).(int16)
@@ -65,3 +71,27 @@ func (s *StateMock) Reproduce(a int16) {
 func (s *StateMock) Impulse(x int16) {
 	s.Called(x)
 }
+
+func (s *StateMock) Give(x int16) {
+	s.Called(x)
+}
+
+func (s *StateMock) Take(x int16) {
+	s.Called(x)
+}
+
+func (s *StateMock) Dock() {
+	s.Called()
+}
+
+func (s *StateMock) Undock() {
+	s.Called()
+}
+
+func (s *StateMock) AttachTether() {
+	s.Called()
+}
+
+func (s *StateMock) ReleaseTether() {
+	s.Called()
+}
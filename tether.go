@@ -0,0 +1,114 @@
+package main
+
+import (
+	"github.com/jakecoffman/cp"
+)
+
+type (
+	// Tether connects a bot to an asteroid, allowing the
+	// bot to tow it.
+	//
+	// The tether is a slide joint which can be shortened but
+	// not stretched. It snaps if the tension exceeds the
+	// breaking force.
+	Tether struct {
+		bot      *Bot
+		asteroid *Asteroid
+
+		joint *cp.Constraint
+		// anchor on the asteroid in body coordinates
+		anchor cp.Vector
+	}
+)
+
+// commitTether applies the tether requests of b
+func (g *Game) commitTether(b *Bot) {
+	if b.intent.Release {
+		g.release(b.tether)
+	}
+	if !b.intent.Tether || b.tether != nil || b.remote == nil {
+		return
+	}
+	if !g.space.ContainsShape(b.remote) {
+		return
+	}
+	a, ok := b.remote.UserData.(*Asteroid)
+	if !ok {
+		return
+	}
+	rng := b.hull.Tether.Range
+	info, offset := b.query(b.remote)
+	if rng <= 0 || info.Distance > rng+b.radius {
+		return
+	}
+	// tethers do not reach across the bounds of the world
+	if offset != (cp.Vector{}) {
+		return
+	}
+	g.attach(b, a, info.Point)
+}
+
+// attach connects b to point (world coordinates) on a
+func (g *Game) attach(b *Bot, a *Asteroid, point cp.Vector) {
+	g.tie(b, a, a.WorldToLocal(point), b.Position().Distance(point))
+}
+
+// tie connects b to anchor (body coordinates) on a with a
+// tether of length
+func (g *Game) tie(b *Bot, a *Asteroid, anchor cp.Vector, length float64) {
+	t := &Tether{
+		bot:      b,
+		asteroid: a,
+		anchor:   anchor,
+	}
+	t.joint = cp.NewSlideJoint(b.Body, a.Body, cp.Vector{}, t.anchor, 0, length)
+	t.joint.PostSolve = func(c *cp.Constraint, sp *cp.Space) {
+		force := c.Class.GetImpulse() / sp.TimeStep()
+		if force <= b.hull.Tether.BreakingForce {
+			return
+		}
+		sp.AddPostStepCallback(func(*cp.Space, interface{}, interface{}) {
+			g.release(t)
+			g.emit(Event{
+				Type:     EventTetherBreak,
+				BotID:    b.id,
+				Position: b.Position(),
+			})
+		}, t, nil)
+	}
+	g.space.AddConstraint(t.joint)
+	b.tether = t
+	g.tethers = append(g.tethers, t)
+}
+
+// release removes the tether t. It is safe to call with
+// already released tethers.
+func (g *Game) release(t *Tether) {
+	if t == nil {
+		return
+	}
+	for i, other := range g.tethers {
+		if other != t {
+			continue
+		}
+		g.space.RemoveConstraint(t.joint)
+		copy(g.tethers[i:], g.tethers[i+1:])
+		g.tethers[len(g.tethers)-1] = nil
+		g.tethers = g.tethers[:len(g.tethers)-1]
+		break
+	}
+	if t.bot.tether == t {
+		t.bot.tether = nil
+	}
+}
+
+// Length returns the maximum length of the tether
+func (t *Tether) Length() float64 {
+	return t.joint.Class.(*cp.SlideJoint).Max
+}
+
+// AnchorPosition returns the world position of the
+// tether's anchor on the asteroid
+func (t *Tether) AnchorPosition() cp.Vector {
+	return t.asteroid.LocalToWorld(t.anchor)
+}
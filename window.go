@@ -0,0 +1,103 @@
+//go:build !headless
+
+package main
+
+import (
+	"flag"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// runWindow runs the game in a window
+//
+//	moonshot [--seed 42] [--record run.replay] [scenario]
+func runWindow(args []string) int {
+	fs := flag.NewFlagSet(title, flag.ContinueOnError)
+	seed := fs.Int64("seed", time.Now().UnixNano(), "world seed")
+	record := fs.String("record", "", "record the game to file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	scenario := "all"
+	if fs.NArg() == 1 {
+		if _, ok := scenarios[fs.Arg(0)]; ok {
+			scenario = fs.Arg(0)
+		}
+	}
+
+	r := NewRenderer()
+	defer r.Close()
+
+	g, err := NewGame(scenario, *seed)
+	if err != nil {
+		errLog.Log("msg", "error creating game", "err", err)
+		return 1
+	}
+	defer g.Close()
+
+	if err := g.EnableHistory(defaultHistoryInterval, defaultHistorySize); err != nil {
+		errLog.Log("msg", "error enabling history", "err", err)
+		return 1
+	}
+
+	if *record != "" {
+		rec, err := RecordFile(g, 1, *record)
+		if err != nil {
+			errLog.Log("msg", "error recording game", "err", err)
+			return 1
+		}
+		defer func() {
+			if err := rec.Close(); err != nil {
+				errLog.Log("msg", "error writing recording", "err", err)
+			}
+		}()
+	}
+
+	rl.SetTargetFPS(60)
+
+	for !rl.WindowShouldClose() {
+		dt := rl.GetFrameTime()
+
+		r.HandleInput(g)
+
+		g.Update(dt)
+
+		r.Draw(g)
+	}
+
+	return 0
+}
+
+// runReplay plays back a recording in a window
+//
+//	moonshot replay run.replay
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	speed := fs.Float64("speed", 1, "playback speed")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		errLog.Log("msg", "expected recording file")
+		return 2
+	}
+	rec, err := ReadRecordingFile(fs.Arg(0))
+	if err != nil {
+		errLog.Log("msg", "error reading recording", "err", err)
+		return 1
+	}
+	p := NewPlayback(rec)
+	p.Speed = *speed
+
+	r := NewRenderer()
+	defer r.Close()
+	rl.SetTargetFPS(60)
+
+	for !rl.WindowShouldClose() {
+		r.HandlePlaybackInput(p)
+		p.Update(rl.GetFrameTime())
+		r.DrawPlayback(p)
+	}
+	return 0
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"math"
+
+	"github.com/jakecoffman/cp"
+	"github.com/pkg/errors"
+)
+
+const (
+	// WorldInfinite is unbounded, objects drift away forever
+	WorldInfinite WorldKind = iota
+	// WorldWalled is bounded by static walls
+	WorldWalled
+	// WorldToroidal wraps around at its bounds. Objects
+	// leaving on one side enter on the opposite side.
+	WorldToroidal
+)
+
+const (
+	// wallRadius is the thickness of walls
+	wallRadius        = 10
+	wallFrictionCoeff = 0.4
+)
+
+type (
+	WorldKind uint8
+
+	// World is the topology of the game's world.
+	//
+	// Distances in a toroidal world are measured to the
+	// closest image of an object across the bounds, bots
+	// see and touch objects on the other side. Physics does
+	// not wrap: objects on opposite sides of the bounds do
+	// not collide, and docks and tethers only connect
+	// objects on the same side. Connected objects wrap
+	// together. Objects only wrap if they do not overlap
+	// anything on the opposite side, otherwise they stay
+	// outside the bounds until there is room.
+	World struct {
+		Kind WorldKind `json:"kind"`
+		// Bounds of walled and toroidal worlds
+		Bounds cp.BB `json:"bounds"`
+	}
+)
+
+// SetWorld sets the topology of the world. Walls of a
+// walled world are added to the space. Worlds with empty
+// bounds are rejected.
+func (g *Game) SetWorld(w World) error {
+	if err := w.validate(); err != nil {
+		return err
+	}
+	g.setWorld(w)
+	return nil
+}
+
+// setWorld sets the valid world w
+func (g *Game) setWorld(w World) {
+	g.removeWalls()
+	g.world = w
+	// the static body is the world, bots find the
+	// topology through it
+	g.space.StaticBody.UserData = &g.world
+	if w.Kind != WorldWalled {
+		return
+	}
+	bb := w.Bounds
+	corners := []cp.Vector{{X: bb.L, Y: bb.B}, {X: bb.R, Y: bb.B}, {X: bb.R, Y: bb.T}, {X: bb.L, Y: bb.T}}
+	for i, a := range corners {
+		s := cp.NewSegment(g.space.StaticBody, a, corners[(i+1)%len(corners)], wallRadius)
+		s.SetFriction(wallFrictionCoeff)
+		s.Filter.Categories = SHAPE_CATEGORY_WALL
+		g.space.AddShape(s)
+		g.walls = append(g.walls, s)
+	}
+}
+
+func (g *Game) removeWalls() {
+	for _, s := range g.walls {
+		g.space.RemoveShape(s)
+	}
+	g.walls = nil
+}
+
+// worldOf returns the topology of the world of space sp
+func worldOf(sp *cp.Space) *World {
+	if w, ok := sp.StaticBody.UserData.(*World); ok {
+		return w
+	}
+	return &World{}
+}
+
+// validate returns an error if w is of no known kind or
+// the bounds of a walled or toroidal world are empty
+func (w World) validate() error {
+	switch w.Kind {
+	case WorldInfinite:
+		return nil
+	case WorldWalled, WorldToroidal:
+	default:
+		return errors.Errorf("unknown world kind %d", w.Kind)
+	}
+	size := w.size()
+	if !(size.X > 0 && size.Y > 0) || math.IsInf(size.X+size.Y, 0) {
+		return errors.Errorf("invalid world bounds %v", w.Bounds)
+	}
+	return nil
+}
+
+// size returns the width and height of the bounds
+func (w *World) size() cp.Vector {
+	return cp.Vector{X: w.Bounds.R - w.Bounds.L, Y: w.Bounds.T - w.Bounds.B}
+}
+
+// images returns the offsets of the images of the world
+// within distance r of p.
+//
+// Objects at q appear at q + offset. The first offset is
+// always zero.
+func (w *World) images(p cp.Vector, r float64) []cp.Vector {
+	offsets := []cp.Vector{{}}
+	if w.Kind != WorldToroidal {
+		return offsets
+	}
+	size := w.size()
+	var xs, ys []float64
+	if p.X-r < w.Bounds.L {
+		xs = append(xs, -size.X)
+	}
+	if p.X+r > w.Bounds.R {
+		xs = append(xs, size.X)
+	}
+	if p.Y-r < w.Bounds.B {
+		ys = append(ys, -size.Y)
+	}
+	if p.Y+r > w.Bounds.T {
+		ys = append(ys, size.Y)
+	}
+	for _, x := range xs {
+		offsets = append(offsets, cp.Vector{X: x})
+	}
+	for _, y := range ys {
+		offsets = append(offsets, cp.Vector{Y: y})
+		for _, x := range xs {
+			offsets = append(offsets, cp.Vector{X: x, Y: y})
+		}
+	}
+	return offsets
+}
+
+// pointQuery finds the closest point on s to p, taking
+// all images of s into account.
+//
+// The point of the result is on s itself, offset is the
+// position of the image it was found on relative to s.
+func (w *World) pointQuery(s *cp.Shape, p cp.Vector) (info cp.PointQueryInfo, offset cp.Vector) {
+	info = s.PointQuery(p)
+	if w.Kind != WorldToroidal {
+		return info, offset
+	}
+	size := w.size()
+	for _, off := range w.images(p, size.Length()) {
+		if off.X == 0 && off.Y == 0 {
+			continue
+		}
+		if other := s.PointQuery(p.Sub(off)); other.Distance < info.Distance {
+			info, offset = other, off
+		}
+	}
+	return info, offset
+}
+
+// wrap returns the position of p inside the bounds and
+// whether it moved
+func (w *World) wrap(p cp.Vector) (cp.Vector, bool) {
+	if w.Kind != WorldToroidal {
+		return p, false
+	}
+	x, movedX := wrapCoord(p.X, w.Bounds.L, w.Bounds.R)
+	y, movedY := wrapCoord(p.Y, w.Bounds.B, w.Bounds.T)
+	return cp.Vector{X: x, Y: y}, movedX || movedY
+}
+
+// wrapCoord returns x wrapped into [min, max) and whether
+// it moved. x is kept if it is not finite or the range is
+// empty.
+func wrapCoord(x, min, max float64) (float64, bool) {
+	if x >= min && x < max || !(max > min) || math.IsNaN(x) || math.IsInf(x, 0) {
+		return x, false
+	}
+	size := max - min
+	x = min + math.Mod(x-min, size)
+	if x < min {
+		x += size
+	}
+	if x >= max {
+		// rounded up to the bound
+		x = min
+	}
+	return x, true
+}
+
+// wrap moves all objects that left a toroidal world to
+// the opposite side.
+//
+// Objects connected by docks or tethers move together,
+// following the first of them. They stay where they are if
+// they would overlap other objects on the opposite side
+// and try again on the next tick.
+func (g *Game) wrap() {
+	if g.world.Kind != WorldToroidal {
+		return
+	}
+	links := make(map[*cp.Body][]*cp.Body)
+	for _, d := range g.docks {
+		links[d.a.Body] = append(links[d.a.Body], d.b.Body)
+		links[d.b.Body] = append(links[d.b.Body], d.a.Body)
+	}
+	for _, t := range g.tethers {
+		links[t.bot.Body] = append(links[t.bot.Body], t.asteroid.Body)
+		links[t.asteroid.Body] = append(links[t.asteroid.Body], t.bot.Body)
+	}
+
+	done := make(map[*cp.Body]bool)
+	// wrapped are the shapes moved so far, the spatial
+	// index still has them at their previous position
+	var wrapped []*cp.Shape
+	move := func(b *cp.Body) {
+		if done[b] {
+			return
+		}
+		p, ok := g.world.wrap(b.Position())
+		offset := p.Sub(b.Position())
+		// the connected objects of b
+		group := []*cp.Body{b}
+		done[b] = true
+		for i := 0; i < len(group); i++ {
+			for _, other := range links[group[i]] {
+				if !done[other] {
+					done[other] = true
+					group = append(group, other)
+				}
+			}
+		}
+		if !ok || !g.vacantFor(group, offset, wrapped) {
+			return
+		}
+		for _, m := range group {
+			m.SetPosition(m.Position().Add(offset))
+			at := transformOf(m)
+			m.EachShape(func(s *cp.Shape) {
+				s.Update(at)
+				wrapped = append(wrapped, s)
+			})
+		}
+	}
+	for _, b := range g.bots {
+		move(b.Body)
+	}
+	for _, a := range g.asteroids {
+		move(a.Body)
+	}
+	for _, d := range g.debris {
+		move(d.Body)
+	}
+}
+
+// vacantFor returns true if the shapes of group moved by
+// offset would not overlap any shape outside of group.
+// moved are the shapes moved since the space was stepped.
+func (g *Game) vacantFor(group []*cp.Body, offset cp.Vector, moved []*cp.Shape) bool {
+	in := make(map[*cp.Body]bool, len(group))
+	for _, m := range group {
+		in[m] = true
+	}
+	vacant := true
+	for _, m := range group {
+		at := cp.NewTransformRigid(m.Position().Add(offset), m.Angle())
+		m.EachShape(func(s *cp.Shape) {
+			if !vacant {
+				return
+			}
+			overlaps := func(other *cp.Shape) {
+				if in[other.Body()] || s.Filter.Reject(other.Filter) {
+					return
+				}
+				if cp.ShapesCollide(s, other).Count > 0 {
+					vacant = false
+				}
+			}
+			// the shape is placed at the destination for the
+			// query and put back afterwards
+			bb := s.Update(at)
+			g.space.BBQuery(bb, s.Filter, func(other *cp.Shape, _ interface{}) {
+				overlaps(other)
+			}, nil)
+			for _, other := range moved {
+				if other.BB().Intersects(bb) {
+					overlaps(other)
+				}
+			}
+			s.Update(transformOf(m))
+		})
+	}
+	return vacant
+}
+
+// transformOf returns the transformation of b from body to
+// world coordinates
+func transformOf(b *cp.Body) cp.Transform {
+	return cp.NewTransformRigid(b.Position(), b.Angle())
+}
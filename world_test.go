@@ -0,0 +1,185 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+	"github.com/stretchr/testify/assert"
+)
+
+var testBounds = cp.BB{L: -500, B: -500, R: 500, T: 500}
+
+func TestWorldImages(t *testing.T) {
+	w := &World{Kind: WorldInfinite, Bounds: testBounds}
+	assert.Len(t, w.images(cp.Vector{X: 490, Y: 490}, 50), 1)
+
+	w.Kind = WorldToroidal
+	assert.Equal(t, []cp.Vector{{}}, w.images(cp.Vector{}, 50))
+	assert.ElementsMatch(t, []cp.Vector{{}, {X: 1000}}, w.images(cp.Vector{X: 490}, 50))
+	assert.ElementsMatch(t,
+		[]cp.Vector{{}, {X: -1000}, {Y: -1000}, {X: -1000, Y: -1000}},
+		w.images(cp.Vector{X: -490, Y: -490}, 50))
+
+	p, ok := w.wrap(cp.Vector{X: 510, Y: -1510})
+	assert.True(t, ok)
+	assert.InDelta(t, -490, p.X, 1e-9)
+	assert.InDelta(t, 490, p.Y, 1e-9)
+	_, ok = w.wrap(cp.Vector{X: 10})
+	assert.False(t, ok)
+
+	// far positions wrap at once
+	p, ok = w.wrap(cp.Vector{X: 1e15 + 10, Y: -1e15})
+	assert.True(t, ok)
+	assert.True(t, testBounds.ContainsVect(p), "%v", p)
+	p, ok = w.wrap(cp.Vector{X: math.Inf(1), Y: math.NaN()})
+	assert.False(t, ok)
+	assert.True(t, math.IsInf(p.X, 1))
+	empty := &World{Kind: WorldToroidal}
+	_, ok = empty.wrap(cp.Vector{X: 10})
+	assert.False(t, ok)
+}
+
+func TestSetInvalidWorld(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	if !assert.NoError(t, g.SetWorld(World{Kind: WorldWalled, Bounds: testBounds})) {
+		return
+	}
+
+	for name, w := range map[string]World{
+		"empty":    {Kind: WorldToroidal},
+		"inverted": {Kind: WorldWalled, Bounds: cp.BB{L: 10, B: 0, R: -10, T: 10}},
+		"infinite": {Kind: WorldToroidal, Bounds: cp.BB{L: math.Inf(-1), B: 0, R: 10, T: 10}},
+		"kind":     {Kind: 7, Bounds: testBounds},
+	} {
+		assert.Error(t, g.SetWorld(w), name)
+	}
+	// the world is unchanged
+	assert.Equal(t, World{Kind: WorldWalled, Bounds: testBounds}, g.world)
+	assert.Len(t, g.walls, 4)
+	assert.NoError(t, g.SetWorld(World{}))
+}
+
+func TestToroidalWorldWraps(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	g.SetWorld(World{Kind: WorldToroidal, Bounds: testBounds})
+
+	d := NewDebris(g.space, 10, cp.Vector{X: 495}, cp.Vector{X: 600})
+	g.debris = append(g.debris, d)
+
+	hull := testHull(t)
+	a := NewBot(g.space, 1, hull)
+	a.SetPosition(cp.Vector{X: 0, Y: 495})
+	a.SetVelocity(0, 600)
+	b := NewBot(g.space, 2, hull)
+	b.SetPosition(cp.Vector{X: 0, Y: 495 - 2*hull.Radius})
+	b.SetVelocity(0, 600)
+	g.bots = append(g.bots, a, b)
+	g.dock(a, b)
+	g.metabolism = Metabolism{}
+
+	g.Tick()
+	assert.Less(t, d.Position().X, -400.)
+	// docked bots wrap together
+	assert.Less(t, a.Position().Y, -400.)
+	assert.InDelta(t, 2*hull.Radius, a.Position().Distance(b.Position()), 1)
+}
+
+func TestWrapOntoObject(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	if !assert.NoError(t, g.SetWorld(World{Kind: WorldToroidal, Bounds: testBounds})) {
+		return
+	}
+
+	d := NewDebris(g.space, 10, cp.Vector{X: 495}, cp.Vector{X: 600})
+	blocker := NewDebris(g.space, 10, cp.Vector{X: -495}, cp.Vector{})
+	g.debris = append(g.debris, d, blocker)
+
+	// the destination is taken, the debris stays outside
+	g.Tick()
+	assert.Greater(t, d.Position().X, 500.)
+	assert.InDelta(t, -495, blocker.Position().X, 1e-9)
+
+	// and wraps once there is room
+	blocker.SetPosition(cp.Vector{X: 0, Y: 300})
+	g.Tick()
+	assert.Less(t, d.Position().X, -400.)
+	assert.Greater(t, d.Position().Y, -1.)
+	assert.Greater(t, d.Position().Distance(blocker.Position()), 2*d.Radius())
+}
+
+func TestScanAcrossBounds(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+
+	hull := testHull(t)
+	b := NewBot(g.space, 1, hull)
+	b.SetPosition(cp.Vector{X: 490})
+	g.bots = append(g.bots, b)
+	d := NewDebris(g.space, 10, cp.Vector{X: -490}, cp.Vector{})
+	g.debris = append(g.debris, d)
+
+	b.observe(g.newScanIndex())
+	assert.Empty(t, b.visible())
+
+	g.SetWorld(World{Kind: WorldToroidal, Bounds: testBounds})
+	b.observe(g.newScanIndex())
+	if assert.Len(t, b.visible(), 1) {
+		assert.Equal(t, d.Shape, b.visible()[0])
+	}
+	info, offset := b.query(d.Shape)
+	assert.InDelta(t, 20-d.Radius(), info.Distance, 1e-9)
+	assert.Equal(t, cp.Vector{X: 1000}, offset)
+}
+
+func TestTouchAcrossBounds(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	g.SetWorld(World{Kind: WorldToroidal, Bounds: testBounds})
+	g.metabolism = Metabolism{}
+
+	hull := testHull(t)
+	a := NewBot(g.space, 1, hull)
+	a.SetPosition(cp.Vector{X: 499.5 - hull.Radius})
+	b := NewBot(g.space, 2, hull)
+	b.SetPosition(cp.Vector{X: -499.5 + hull.Radius})
+	g.bots = append(g.bots, a, b)
+	g.space.Step(timeStep)
+
+	a.remote = b.Shape
+	mass := b.Mass()
+	a.Reset()
+	a.Give(10)
+	a.Dock()
+	g.commit()
+
+	// transfers reach across, docks do not
+	assert.Greater(t, b.Mass(), mass)
+	assert.Empty(t, g.docks)
+}
+
+func TestWalledWorld(t *testing.T) {
+	g := &Game{}
+	g.init()
+	defer g.Close()
+	g.SetWorld(World{Kind: WorldWalled, Bounds: testBounds})
+	assert.Len(t, g.walls, 4)
+
+	d := NewDebris(g.space, 10, cp.Vector{}, cp.Vector{X: 300, Y: -200})
+	g.debris = append(g.debris, d)
+	for i := 0; i < 600; i++ {
+		g.Tick()
+	}
+	assert.True(t, testBounds.ContainsVect(d.Position()), "%v", d.Position())
+
+	g.SetWorld(World{})
+	assert.Empty(t, g.walls)
+}